@@ -0,0 +1,174 @@
+// Package apperrors holds the errors returned to API clients. Each one carries the HTTP status and a
+// stable code clients can switch on, its message is a catalog key translated by the error handler.
+package apperrors
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/go-sql-driver/mysql"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Codes shared by every resource, domain errors such as models.ErrRoleInUse have their own
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidId        = "invalid_id"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeDuplicate        = "duplicate_entry"
+	CodeStillReferenced  = "still_referenced"
+	CodeInvalidReference = "invalid_reference"
+	CodeGone             = "gone"
+	CodeTooLarge         = "too_large"
+	CodeTooManyRequests  = "too_many_requests"
+	CodeInternal         = "internal_error"
+)
+
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	// Params replace {0}, {1}... in Message
+	Params []string
+	Err    error
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	for i, param := range e.Params {
+		message = strings.ReplaceAll(message, "{"+strconv.Itoa(i)+"}", param)
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches on the code, so copies made by With and Wrap still match their sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// With returns a copy of the error with the message parameters filled in
+func (e *Error) With(params ...string) *Error {
+	copied := *e
+	copied.Params = params
+	return &copied
+}
+
+// Wrap returns a copy of the error recording the cause, which is logged but never sent to clients
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+// From turns any error into an *Error. Errors that are not recognized become a 500, their
+// details are kept in Err for the log instead of being shown to the client.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		if appErr != err {
+			// The detail added by fmt.Errorf("%w: ...") around a sentinel is for the log, clients
+			// get the catalog message of the sentinel
+			wrapped := *appErr
+			wrapped.Err = err
+			return &wrapped
+		}
+		return appErr
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return New(http.StatusUnprocessableEntity, CodeValidation, "The given data was invalid").Wrap(err)
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message, ok := httpErr.Message.(string)
+		if !ok {
+			message = http.StatusText(httpErr.Code)
+		}
+		return New(httpErr.Code, CodeFor(httpErr.Code), message).Wrap(httpErr.Internal)
+	}
+
+	// Multipart forms read by FormFile
+	if errors.Is(err, http.ErrMissingFile) {
+		return New(http.StatusBadRequest, CodeBadRequest, "A file is required").Wrap(err)
+	}
+	var protocolErr *http.ProtocolError
+	if errors.As(err, &protocolErr) {
+		return New(http.StatusBadRequest, CodeBadRequest, "The request is not a valid multipart form").Wrap(err)
+	}
+	if errors.Is(err, multipart.ErrMessageTooLarge) {
+		return New(http.StatusRequestEntityTooLarge, CodeTooLarge, http.StatusText(http.StatusRequestEntityTooLarge)).Wrap(err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return New(http.StatusNotFound, CodeNotFound, "record not found")
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			return New(http.StatusConflict, CodeDuplicate, "A record with the same value already exists").Wrap(err)
+		case 1451:
+			return New(http.StatusConflict, CodeStillReferenced, "The record is still used by other data").Wrap(err)
+		case 1452:
+			return New(http.StatusUnprocessableEntity, CodeInvalidReference, "A referenced record does not exist").Wrap(err)
+		}
+	}
+
+	return New(http.StatusInternalServerError, CodeInternal, "Internal Server Error").Wrap(err)
+}
+
+// CodeFor is the code of errors that only have an HTTP status
+func CodeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	default:
+		if status >= http.StatusInternalServerError {
+			return CodeInternal
+		}
+		return CodeBadRequest
+	}
+}
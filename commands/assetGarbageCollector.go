@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"log"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AssetGarbageCollector deletes assets nobody has referenced for longer than the grace period,
+// together with resumable uploads that expired before they were finalized
+func AssetGarbageCollector(cfg *config.Config, db *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("assets:gc", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only list the assets that would be deleted")
+	grace := flags.Duration("grace", 7*24*time.Hour, "how long an asset must be unreferenced before it is deleted")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-*grace)
+	assetModel := models.NewAssetModel(db)
+	assets, err := assetModel.GetUnreferenced(cutoff)
+	if err != nil {
+		return err
+	}
+
+	storage, err := config.NewStorage(cfg)
+	if err != nil {
+		return err
+	}
+	privateStorage, err := config.NewPrivateStorage(cfg)
+	if err != nil {
+		return err
+	}
+
+	imageHelpers := map[string]*helpers.ImageHelper{}
+	deleted := 0
+	for _, asset := range assets {
+		if *dryRun {
+			log.Printf("Would delete asset %s (%s)", asset.ID, asset.Path)
+			continue
+		}
+
+		categoryStorage := storage
+		if asset.Visibility == structs.AssetPrivate {
+			categoryStorage = privateStorage
+		}
+		imageHelper, ok := imageHelpers[asset.Category]
+		if !ok {
+			imageHelper = helpers.NewImageHelper(categoryStorage, asset.Category, helpers.ImageOptions{})
+			imageHelpers[asset.Category] = imageHelper
+		}
+
+		// The asset is checked again under a row lock, an upload of the same content may have reused it
+		purged, err := assetModel.Purge(context.Background(), asset.ID, cutoff, func(asset structs.Asset) error {
+			if strings.HasPrefix(asset.Path, "files/") {
+				return categoryStorage.Delete(asset.Path)
+			}
+			// Assets stored before their sizes were recorded may have any of the configured ones
+			sizes := []int(asset.Variants)
+			if sizes == nil {
+				sizes = cfg.AssetStorage.ImageVariants
+			}
+			return imageHelper.Delete(asset.Path, sizes)
+		})
+		if err != nil {
+			helpers.HandleError("Failed to delete asset "+asset.ID.String(), err)
+			continue
+		}
+		if !purged {
+			continue
+		}
+		log.Printf("Deleted asset %s (%s)", asset.ID, asset.Path)
+		deleted++
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d unreferenced assets older than %s", len(assets), *grace)
+	} else {
+		log.Printf("Deleted %d of %d unreferenced assets older than %s", deleted, len(assets), *grace)
+	}
+
+	return purgeExpiredUploads(cfg, db, *dryRun)
+}
+
+func purgeExpiredUploads(cfg *config.Config, db *gorm.DB, dryRun bool) error {
+	uploadModel := models.NewAssetUploadModel(db)
+	uploads, err := uploadModel.GetExpired(time.Now())
+	if err != nil {
+		return err
+	}
+
+	privateStorage, err := config.NewPrivateStorage(cfg)
+	if err != nil {
+		return err
+	}
+	uploadHelper := helpers.NewUploadHelper(privateStorage)
+
+	deleted := 0
+	for _, upload := range uploads {
+		if dryRun {
+			log.Printf("Would delete expired upload %s (%s)", upload.ID, upload.Filename)
+			continue
+		}
+
+		if err := uploadHelper.Remove(upload); err != nil {
+			helpers.HandleError("Failed to delete upload chunks "+upload.ID.String(), err)
+			continue
+		}
+		if err := uploadModel.Delete(context.Background(), upload.ID); err != nil {
+			helpers.HandleError("Failed to delete upload "+upload.ID.String(), err)
+			continue
+		}
+		deleted++
+	}
+
+	if dryRun {
+		log.Printf("Dry run: %d expired uploads", len(uploads))
+	} else {
+		log.Printf("Deleted %d of %d expired uploads", deleted, len(uploads))
+	}
+	return nil
+}
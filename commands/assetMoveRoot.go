@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AssetMoveRoot moves the images written under the storage root used before ASSET_STORAGE_PATH, the
+// working directory, into the configured public or private storage. Their keys and URLs stay the same.
+func AssetMoveRoot(cfg *config.Config, db *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("assets:move-root", flag.ContinueOnError)
+	from := flags.String("from", "./", "the old storage root holding the images directory")
+	dryRun := flags.Bool("dry-run", false, "only list the files that would be moved")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if cfg.AssetStorage.Driver == "local" && samePath(*from, cfg.AssetStorage.Path) {
+		return fmt.Errorf("%s is already ASSET_STORAGE_PATH", *from)
+	}
+
+	storage, err := config.NewStorage(cfg)
+	if err != nil {
+		return err
+	}
+	privateStorage, err := config.NewPrivateStorage(cfg)
+	if err != nil {
+		return err
+	}
+
+	moved := 0
+	err = filepath.WalkDir(filepath.Join(*from, "images"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(*from, path)
+		if err != nil {
+			return err
+		}
+		// Keys are images/<category>/<file>
+		key := filepath.ToSlash(rel)
+		parts := strings.Split(key, "/")
+		if len(parts) != 3 {
+			log.Printf("Skipping %s, it is not an asset", path)
+			return nil
+		}
+
+		if *dryRun {
+			log.Printf("Would move %s", key)
+			return nil
+		}
+
+		target := storage
+		if cfg.AssetStorage.IsPrivate(parts[1]) {
+			target = privateStorage
+		}
+		if err := moveFile(target, path, key); err != nil {
+			return fmt.Errorf("moving %s: %w", path, err)
+		}
+		moved++
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("No images under %s, nothing to move", *from)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !*dryRun {
+		log.Printf("Moved %d images from %s", moved, *from)
+	}
+	return nil
+}
+
+// moveFile writes a file to the storage and removes it once it is stored
+func moveFile(storage helpers.Storage, path, key string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := storage.Put(key, f, info.Size(), mime.TypeByExtension(filepath.Ext(path))); err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(path)
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
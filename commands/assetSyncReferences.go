@@ -0,0 +1,22 @@
+package commands
+
+import (
+	"context"
+	"log"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/models"
+
+	"gorm.io/gorm"
+)
+
+// AssetSyncReferences recomputes the reference count of every asset. Assets referenced before reference
+// counting existed start with a count of zero, which the garbage collector takes for unused. Run it
+// once after upgrading, before the first assets:gc and while no instance is serving requests.
+func AssetSyncReferences(cfg *config.Config, db *gorm.DB, args []string) error {
+	if err := models.NewAssetModel(db).SyncReferenceCounts(context.Background()); err != nil {
+		return err
+	}
+
+	log.Println("Recounted the references of every asset")
+	return nil
+}
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"fmt"
+	"simple-crud-rnd/config"
+
+	"gorm.io/gorm"
+)
+
+// Run executes a maintenance command such as `go run main.go assets:gc -dry-run`
+func Run(cfg *config.Config, db *gorm.DB, args []string) error {
+	switch args[0] {
+	case "assets:gc":
+		return AssetGarbageCollector(cfg, db, args[1:])
+	case "assets:move-root":
+		return AssetMoveRoot(cfg, db, args[1:])
+	case "assets:sync-references":
+		return AssetSyncReferences(cfg, db, args[1:])
+	case "users:create-admin":
+		return CreateAdmin(cfg, db, args[1:])
+	default:
+		return fmt.Errorf("unknown command %s", args[0])
+	}
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CreateAdmin gives the Super Admin role to the user with the email, creating the user when it
+// does not exist yet. This is how the first administrator of a fresh install is made, sign ups
+// only ever get SIGNUP_ROLE. The password is read from ADMIN_PASSWORD or else from stdin, so it
+// does not end up in the shell history.
+func CreateAdmin(cfg *config.Config, db *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("users:create-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the administrator")
+	name := flags.String("name", "Administrator", "name given to a new user")
+	phoneNumber := flags.String("phone", "", "phone number given to a new user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	role, err := models.NewUserRoleModel(db).GetByName(structs.SuperAdminRole)
+	if err != nil {
+		return err
+	}
+
+	userModel := models.NewUserModel(db)
+	user, err := userModel.GetByEmail(*email)
+	if err == nil {
+		if err := userModel.SetRole(context.Background(), user.ID, role.ID); err != nil {
+			return err
+		}
+		log.Printf("Gave %s the %s role", *email, structs.SuperAdminRole)
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	password, err := adminPassword()
+	if err != nil {
+		return err
+	}
+	if _, err := userModel.Create(context.Background(), &structs.UserRequest{
+		Name:        *name,
+		Email:       *email,
+		PhoneNumber: *phoneNumber,
+		Password:    password,
+		UserRolesId: role.ID.String(),
+	}); err != nil {
+		return err
+	}
+	log.Printf("Created %s with the %s role", *email, structs.SuperAdminRole)
+	return nil
+}
+
+func adminPassword() (string, error) {
+	password, ok := os.LookupEnv("ADMIN_PASSWORD")
+	if !ok {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading the password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < 8 {
+		return "", errors.New("the password must be at least 8 characters")
+	}
+	return password, nil
+}
@@ -2,9 +2,14 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
+	"path/filepath"
 	"simple-crud-rnd/structs"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/joho/godotenv"
@@ -17,7 +22,10 @@ type (
 		Database     Database
 		HTTP         HTTP
 		JWT          JWT
+		Auth         Auth
+		Sales        Sales
 		AssetStorage AssetStorage
+		Language     Language
 	}
 	Database struct {
 		Username string
@@ -31,13 +39,50 @@ type (
 		Port          int
 		Domain        string
 		AssetEndpoint string
+		// TrustedProxies may set the client IP through X-Forwarded-For, without any the IP is the peer's
+		TrustedProxies []*net.IPNet
 	}
 	JWT struct {
-		Secret []byte
-		Config echojwt.Config
+		Secret          []byte
+		Config          echojwt.Config
+		AccessTokenTTL  time.Duration
+		RefreshTokenTTL time.Duration
+	}
+	Auth struct {
+		SignUpRole string
+	}
+	S3 struct {
+		Endpoint      string
+		AccessKey     string
+		SecretKey     string
+		Bucket        string
+		Region        string
+		UseSSL        bool
+		PublicURL     string
+		PrivateBucket string
+		Timeout       time.Duration
+	}
+	Sales struct {
+		TaxRate float64
+	}
+	Language struct {
+		Default string
 	}
 	AssetStorage struct {
-		Path string
+		Driver            string
+		Path              string
+		PrivatePath       string
+		PrivateCategories []string
+		SigningSecret     []byte
+		SignedURLTTL      time.Duration
+		UploadExpiry      time.Duration
+		FileCategories    []string
+		FileMaxSize       int64
+		S3                S3
+		ImageMaxSize      int64
+		ImageMaxWidth     int
+		ImageMaxHeight    int
+		ImageVariants     []int
 	}
 )
 
@@ -62,6 +107,15 @@ func LoadConfig() (*Config, error) {
 	}
 	domain, _ := configDefaults("DOMAIN", "http://localhost")
 	assetPath, _ := configDefaults("ASSET_PATH", "api/v1/assets")
+	trustedProxies, _ := configDefaults("TRUSTED_PROXIES", "")
+	ipTrustedProxies := []*net.IPNet{}
+	for _, proxy := range splitList(trustedProxies) {
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			log.Fatal("TRUSTED_PROXIES must be a comma separated list of CIDR ranges")
+		}
+		ipTrustedProxies = append(ipTrustedProxies, ipNet)
+	}
 	jwtSecret, _ := configDefaults("JWT_SECRET", "")
 	config := echojwt.Config{
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
@@ -69,7 +123,100 @@ func LoadConfig() (*Config, error) {
 		},
 		SigningKey: []byte(jwtSecret),
 	}
-	storagePath, _ := configDefaults("ASSET_PATH", "./")
+	accessTokenTTL, _ := configDefaults("JWT_ACCESS_TTL", "15m")
+	durAccessTokenTTL, err := time.ParseDuration(accessTokenTTL)
+	if err != nil {
+		log.Fatal("JWT_ACCESS_TTL must be a duration")
+	}
+	refreshTokenTTL, _ := configDefaults("JWT_REFRESH_TTL", "168h")
+	durRefreshTokenTTL, err := time.ParseDuration(refreshTokenTTL)
+	if err != nil {
+		log.Fatal("JWT_REFRESH_TTL must be a duration")
+	}
+	signUpRole, _ := configDefaults("SIGNUP_ROLE", "Member")
+	taxRate, _ := configDefaults("SALES_TAX_RATE", "11")
+	floatTaxRate, err := strconv.ParseFloat(taxRate, 64)
+	if err != nil {
+		log.Fatal("SALES_TAX_RATE must be a number")
+	}
+	defaultLanguage, _ := configDefaults("DEFAULT_LANGUAGE", "en")
+	storageDriver, _ := configDefaults("STORAGE_DRIVER", "local")
+	if storageDriver != "local" && storageDriver != "s3" {
+		log.Fatal("STORAGE_DRIVER must be local or s3")
+	}
+	// ASSET_PATH named the storage directory as well before ASSET_STORAGE_PATH existed
+	storagePath, storageConfigured := configFallback("ASSET_STORAGE_PATH", "ASSET_PATH", "./storage/public")
+	// Images used to be written under the working directory, they are not served from the new root
+	if _, err := os.Stat("images"); !storageConfigured && err == nil {
+		log.Printf("Found images under the old storage root ./, run `go run main.go assets:move-root` to move them to %s", storagePath)
+	}
+	privateStoragePath, _ := configDefaults("ASSET_PRIVATE_STORAGE_PATH", "./storage/private")
+	if isSubPath(storagePath, privateStoragePath) {
+		log.Fatal("ASSET_PRIVATE_STORAGE_PATH must not be inside ASSET_STORAGE_PATH, it would be served publicly")
+	}
+	privateCategories, _ := configDefaults("ASSET_PRIVATE_CATEGORIES", "profile_photos,invoices")
+	signingSecret, _ := configDefaults("ASSET_SIGNING_SECRET", "")
+	if signingSecret == "" || signingSecret == jwtSecret {
+		log.Fatal("ASSET_SIGNING_SECRET must be set to a secret of its own, not JWT_SECRET")
+	}
+	signedURLTTL, _ := configDefaults("ASSET_SIGNED_URL_TTL", "15m")
+	durSignedURLTTL, err := time.ParseDuration(signedURLTTL)
+	if err != nil {
+		log.Fatal("ASSET_SIGNED_URL_TTL must be a duration")
+	}
+	uploadExpiry, _ := configDefaults("ASSET_UPLOAD_EXPIRY", "24h")
+	durUploadExpiry, err := time.ParseDuration(uploadExpiry)
+	if err != nil {
+		log.Fatal("ASSET_UPLOAD_EXPIRY must be a duration")
+	}
+	fileCategories, _ := configDefaults("ASSET_FILE_CATEGORIES", "documents,imports")
+	fileMaxSize, _ := configDefaults("FILE_MAX_SIZE", "104857600")
+	intFileMaxSize, err := strconv.ParseInt(fileMaxSize, 10, 64)
+	if err != nil {
+		log.Fatal("FILE_MAX_SIZE must be a number of bytes")
+	}
+	s3Endpoint, _ := configDefaults("S3_ENDPOINT", "127.0.0.1:9000")
+	s3AccessKey, _ := configDefaults("S3_ACCESS_KEY", "")
+	s3SecretKey, _ := configDefaults("S3_SECRET_KEY", "")
+	s3Bucket, _ := configDefaults("S3_BUCKET", "assets")
+	s3Region, _ := configDefaults("S3_REGION", "us-east-1")
+	s3UseSSL, _ := configDefaults("S3_USE_SSL", "false")
+	boolS3UseSSL, err := strconv.ParseBool(s3UseSSL)
+	if err != nil {
+		log.Fatal("S3_USE_SSL must be true or false")
+	}
+	s3PublicURL, _ := configDefaults("S3_PUBLIC_URL", "")
+	s3PrivateBucket, _ := configDefaults("S3_PRIVATE_BUCKET", "private-assets")
+	s3Timeout, _ := configDefaults("S3_TIMEOUT", "1m")
+	durS3Timeout, err := time.ParseDuration(s3Timeout)
+	if err != nil || durS3Timeout <= 0 {
+		log.Fatal("S3_TIMEOUT must be a positive duration")
+	}
+
+	imageMaxSize, _ := configDefaults("IMAGE_MAX_SIZE", "5242880")
+	intImageMaxSize, err := strconv.ParseInt(imageMaxSize, 10, 64)
+	if err != nil {
+		log.Fatal("IMAGE_MAX_SIZE must be a number of bytes")
+	}
+	imageMaxWidth, _ := configDefaults("IMAGE_MAX_WIDTH", "4096")
+	intImageMaxWidth, err := strconv.Atoi(imageMaxWidth)
+	if err != nil {
+		log.Fatal("IMAGE_MAX_WIDTH must be a number")
+	}
+	imageMaxHeight, _ := configDefaults("IMAGE_MAX_HEIGHT", "4096")
+	intImageMaxHeight, err := strconv.Atoi(imageMaxHeight)
+	if err != nil {
+		log.Fatal("IMAGE_MAX_HEIGHT must be a number")
+	}
+	imageVariants, _ := configDefaults("IMAGE_VARIANTS", "64,256,1024")
+	intImageVariants := []int{}
+	for _, variant := range splitList(imageVariants) {
+		intVariant, err := strconv.Atoi(variant)
+		if err != nil || intVariant <= 0 {
+			log.Fatal("IMAGE_VARIANTS must be a comma separated list of positive numbers")
+		}
+		intImageVariants = append(intImageVariants, intVariant)
+	}
 
 	var cfg Config = Config{
 		Database: Database{
@@ -80,17 +227,52 @@ func LoadConfig() (*Config, error) {
 			Name:     dbName,
 		},
 		HTTP: HTTP{
-			Host:          listenHost,
-			Port:          intListenPort,
-			Domain:        domain,
-			AssetEndpoint: assetPath,
+			Host:           listenHost,
+			Port:           intListenPort,
+			Domain:         domain,
+			AssetEndpoint:  assetPath,
+			TrustedProxies: ipTrustedProxies,
 		},
 		JWT: JWT{
-			Secret: []byte(jwtSecret),
-			Config: config,
+			Secret:          []byte(jwtSecret),
+			Config:          config,
+			AccessTokenTTL:  durAccessTokenTTL,
+			RefreshTokenTTL: durRefreshTokenTTL,
+		},
+		Auth: Auth{
+			SignUpRole: signUpRole,
+		},
+		Sales: Sales{
+			TaxRate: floatTaxRate,
 		},
 		AssetStorage: AssetStorage{
-			Path: storagePath,
+			Driver:            storageDriver,
+			Path:              storagePath,
+			PrivatePath:       privateStoragePath,
+			PrivateCategories: splitList(privateCategories),
+			SigningSecret:     []byte(signingSecret),
+			SignedURLTTL:      durSignedURLTTL,
+			UploadExpiry:      durUploadExpiry,
+			FileCategories:    splitList(fileCategories),
+			FileMaxSize:       intFileMaxSize,
+			S3: S3{
+				Endpoint:      s3Endpoint,
+				AccessKey:     s3AccessKey,
+				SecretKey:     s3SecretKey,
+				Bucket:        s3Bucket,
+				Region:        s3Region,
+				UseSSL:        boolS3UseSSL,
+				PublicURL:     s3PublicURL,
+				PrivateBucket: s3PrivateBucket,
+				Timeout:       durS3Timeout,
+			},
+			ImageMaxSize:   intImageMaxSize,
+			ImageMaxWidth:  intImageMaxWidth,
+			ImageMaxHeight: intImageMaxHeight,
+			ImageVariants:  intImageVariants,
+		},
+		Language: Language{
+			Default: defaultLanguage,
 		},
 	}
 
@@ -105,3 +287,40 @@ func configDefaults(env, defaults string) (string, bool) {
 	}
 	return value, ok
 }
+
+// configFallback reads env, or the older name it replaced when only that one is set
+func configFallback(env, legacy, defaults string) (string, bool) {
+	if _, ok := os.LookupEnv(env); !ok {
+		if value, ok := os.LookupEnv(legacy); ok {
+			log.Printf("%s is unset. Using %s=%s, which is deprecated for this setting", env, legacy, value)
+			return value, ok
+		}
+	}
+	return configDefaults(env, defaults)
+}
+
+// IsPrivate reports whether assets of the category are only reachable through signed URLs
+func (as AssetStorage) IsPrivate(category string) bool {
+	return slices.Contains(as.PrivateCategories, category)
+}
+
+// splitList parses a comma separated env value, ignoring blanks
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func isSubPath(parent, child string) bool {
+	absParent, errParent := filepath.Abs(parent)
+	absChild, errChild := filepath.Abs(child)
+	if errParent != nil || errChild != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absParent, absChild)
+	return err == nil && filepath.IsLocal(rel)
+}
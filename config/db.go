@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"simple-crud-rnd/helpers"
 	"simple-crud-rnd/structs"
 
 	"gorm.io/driver/mysql"
@@ -24,13 +25,64 @@ func InitDatabase(cfg *Config) (*gorm.DB, error) {
 
 	log.Println("Succees to connect to database")
 
+	if err := db.Use(helpers.AuditColumns{}); err != nil {
+		log.Fatal("Failed to register the audit callbacks:", err)
+	}
+	if err := db.Use(helpers.AuditLogs{Tables: []string{
+		structs.User{}.TableName(),
+		structs.Customer{}.TableName(),
+		structs.Product{}.TableName(),
+		structs.Sale{}.TableName(),
+	}}); err != nil {
+		log.Fatal("Failed to register the audit log callbacks:", err)
+	}
+
 	if err := db.AutoMigrate(
+		&structs.UserRole{},
 		&structs.User{},
+		&structs.RefreshToken{},
+		&structs.Customer{},
+		&structs.ProductCategory{},
+		&structs.Product{},
+		&structs.ProductDetail{},
+		&structs.Sale{},
+		&structs.SaleDetail{},
+		&structs.InvoiceSequence{},
+		&structs.Asset{},
+		&structs.AssetReference{},
+		&structs.AssetUpload{},
+		&structs.AuditLog{},
 	); err != nil {
 		log.Fatal("Failed to migrate to database:", err)
 	}
 
+	// Assets were unique per content before every uploader got their own, AutoMigrate only adds indexes
+	if db.Migrator().HasIndex(&structs.Asset{}, "idx_asset_content") {
+		if err := db.Migrator().DropIndex(&structs.Asset{}, "idx_asset_content"); err != nil {
+			log.Fatal("Failed to migrate to database:", err)
+		}
+	}
+
 	log.Println("Succees to migrate to database")
 
+	if err := seedRoles(db, cfg); err != nil {
+		log.Fatal("Failed to seed roles:", err)
+	}
+
 	return db, err
 }
+
+// seedRoles makes sure the super admin role and the role given to self sign-ups exist. Nobody holds
+// the super admin role until `users:create-admin` gives it to someone.
+func seedRoles(db *gorm.DB, cfg *Config) error {
+	roles := []structs.UserRole{
+		{Name: structs.SuperAdminRole, Permissions: structs.Permissions{structs.PermissionAll}},
+		{Name: cfg.Auth.SignUpRole, Permissions: structs.Permissions{}},
+	}
+	for _, role := range roles {
+		if err := db.Where("name = ?", role.Name).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"simple-crud-rnd/helpers"
+)
+
+// NewStorage returns the asset storage selected by STORAGE_DRIVER
+func NewStorage(cfg *Config) (helpers.Storage, error) {
+	if cfg.AssetStorage.Driver == "s3" {
+		return helpers.NewS3Storage(helpers.S3Options{
+			Endpoint:  cfg.AssetStorage.S3.Endpoint,
+			AccessKey: cfg.AssetStorage.S3.AccessKey,
+			SecretKey: cfg.AssetStorage.S3.SecretKey,
+			Bucket:    cfg.AssetStorage.S3.Bucket,
+			Region:    cfg.AssetStorage.S3.Region,
+			UseSSL:    cfg.AssetStorage.S3.UseSSL,
+			PublicURL: cfg.AssetStorage.S3.PublicURL,
+			Timeout:   cfg.AssetStorage.S3.Timeout,
+		})
+	}
+	return helpers.NewLocalStorage(cfg.AssetStorage.Path, fmt.Sprintf("%s/%s", cfg.HTTP.Domain, cfg.HTTP.AssetEndpoint))
+}
+
+// NewPrivateStorage returns the storage of private assets, whose URLs are signed and served by the API
+func NewPrivateStorage(cfg *Config) (helpers.Storage, error) {
+	var storage helpers.Storage
+	var err error
+	if cfg.AssetStorage.Driver == "s3" {
+		storage, err = helpers.NewS3Storage(helpers.S3Options{
+			Endpoint:  cfg.AssetStorage.S3.Endpoint,
+			AccessKey: cfg.AssetStorage.S3.AccessKey,
+			SecretKey: cfg.AssetStorage.S3.SecretKey,
+			Bucket:    cfg.AssetStorage.S3.PrivateBucket,
+			Region:    cfg.AssetStorage.S3.Region,
+			UseSSL:    cfg.AssetStorage.S3.UseSSL,
+			Timeout:   cfg.AssetStorage.S3.Timeout,
+		})
+	} else {
+		storage, err = helpers.NewLocalStorage(cfg.AssetStorage.PrivatePath, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return helpers.NewSignedStorage(storage, fmt.Sprintf("%s/api/v1/files", cfg.HTTP.Domain),
+		cfg.AssetStorage.SigningSecret, cfg.AssetStorage.SignedURLTTL), nil
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type AssetController struct {
+	db             *gorm.DB
+	model          *models.AssetModel
+	cfg            *config.Config
+	imageHelpers   map[string]*helpers.ImageHelper
+	privateStorage helpers.Storage
+}
+
+func NewAssetController(db *gorm.DB, model *models.AssetModel, cfg *config.Config, imageHelpers []*helpers.ImageHelper, privateStorage helpers.Storage) *AssetController {
+	helperByCategory := map[string]*helpers.ImageHelper{}
+	for _, imageHelper := range imageHelpers {
+		helperByCategory[imageHelper.Category()] = imageHelper
+	}
+	return &AssetController{db, model, cfg, helperByCategory, privateStorage}
+}
+
+// Upload stores a multipart "file" under the given "category" and returns the asset ID
+// that other resources reference instead of embedding the file.
+func (ah *AssetController) Upload(c echo.Context) error {
+	imageHelper, ok := ah.imageHelpers[c.FormValue("category")]
+	if !ok {
+		return helpers.Response(c, http.StatusBadRequest, nil, "Unknown asset category")
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return err
+	}
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	staged, err := imageHelper.Stage(src)
+	if err != nil {
+		return err
+	}
+
+	asset, err := storeAsset(c.Request().Context(), ah.cfg, ah.model, imageHelper.Category(), staged, fileHeader.Filename)
+	if err != nil {
+		return err
+	}
+	asset.URL = imageHelper.URL(asset.Path)
+
+	return helpers.Response(c, http.StatusCreated, asset, "")
+}
+
+// Private streams a private asset, the signature has already been checked by VerifySignedURL
+func (ah *AssetController) Private(c echo.Context) error {
+	key, err := url.PathUnescape(c.Param("*"))
+	if err != nil {
+		return apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "Invalid asset path").Wrap(err)
+	}
+
+	f, err := ah.privateStorage.Get(key)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "private, max-age=300")
+	return c.Stream(http.StatusOK, mime.TypeByExtension(filepath.Ext(key)), f)
+}
+
+// storeAsset records staged content as an asset of the uploader, the actor of ctx, and writes it.
+// Uploading the same content again returns the uploader's existing asset, other uploaders' assets
+// share the file.
+func storeAsset(ctx context.Context, cfg *config.Config, model *models.AssetModel, category string, staged *helpers.StagedContent, originalName string) (structs.Asset, error) {
+	asset := structs.Asset{
+		Category:     category,
+		Visibility:   structs.AssetPublic,
+		Path:         staged.Path,
+		OriginalName: originalName,
+		MimeType:     staged.ContentType,
+		Size:         staged.Size,
+		Hash:         &staged.Hash,
+		Variants:     staged.Variants,
+	}
+	if cfg.AssetStorage.IsPrivate(category) {
+		asset.Visibility = structs.AssetPrivate
+	}
+
+	// Reserve before writing, so the garbage collector can not delete the file in between
+	if err := model.Reserve(ctx, &asset); err != nil {
+		return asset, err
+	}
+	// The uploader stored this content before under another extension
+	if asset.Path != staged.Path {
+		return asset, nil
+	}
+	return asset, staged.Write()
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// maxChunkSize caps the body of a single PATCH request
+const maxChunkSize = 16 << 20
+
+var (
+	ErrUploadForbidden = apperrors.New(http.StatusForbidden, "upload_forbidden", "Upload belongs to another user")
+	ErrUploadExpired   = apperrors.New(http.StatusGone, "upload_expired", "Upload has expired")
+)
+
+type AssetUploadController struct {
+	db           *gorm.DB
+	model        *models.AssetUploadModel
+	assetModel   *models.AssetModel
+	cfg          *config.Config
+	uploadHelper *helpers.UploadHelper
+	imageHelpers map[string]*helpers.ImageHelper
+	fileHelpers  map[string]*helpers.FileHelper
+}
+
+func NewAssetUploadController(db *gorm.DB, model *models.AssetUploadModel, assetModel *models.AssetModel, cfg *config.Config, uploadHelper *helpers.UploadHelper, imageHelpers []*helpers.ImageHelper, fileHelpers []*helpers.FileHelper) *AssetUploadController {
+	imageHelperByCategory := map[string]*helpers.ImageHelper{}
+	for _, imageHelper := range imageHelpers {
+		imageHelperByCategory[imageHelper.Category()] = imageHelper
+	}
+	fileHelperByCategory := map[string]*helpers.FileHelper{}
+	for _, fileHelper := range fileHelpers {
+		fileHelperByCategory[fileHelper.Category()] = fileHelper
+	}
+	return &AssetUploadController{
+		db:           db,
+		model:        model,
+		assetModel:   assetModel,
+		cfg:          cfg,
+		uploadHelper: uploadHelper,
+		imageHelpers: imageHelperByCategory,
+		fileHelpers:  fileHelperByCategory,
+	}
+}
+
+// Create starts a resumable upload, the client then sends chunks with Patch and calls Finalize
+func (auh *AssetUploadController) Create(c echo.Context) error {
+	var request structs.AssetUploadRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	maxSize, ok := auh.maxSize(request.Category)
+	if !ok {
+		return helpers.Response(c, http.StatusBadRequest, nil, "Unknown asset category")
+	}
+	if request.Size > maxSize {
+		return helpers.Response(c, http.StatusBadRequest, nil, helpers.T(c, "File is larger than {0} bytes", strconv.FormatInt(maxSize, 10)))
+	}
+	// Checked again on the content by Finalize, this only spares uploading a file that is refused anyway
+	if fileHelper, ok := auh.fileHelpers[request.Category]; ok && !fileHelper.Accepts(filepath.Ext(request.Filename)) {
+		return helpers.ErrUnsupportedFile
+	}
+
+	upload := structs.AssetUpload{
+		Category:  request.Category,
+		Filename:  filepath.Base(request.Filename),
+		Size:      request.Size,
+		ExpiresAt: time.Now().Add(auh.cfg.AssetStorage.UploadExpiry),
+	}
+
+	if err := auh.model.Create(c.Request().Context(), &upload); err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Upload-Offset", "0")
+	return helpers.Response(c, http.StatusCreated, upload, "")
+}
+
+func (auh *AssetUploadController) GetById(c echo.Context) error {
+	upload, err := auh.getUpload(c)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	return helpers.Response(c, http.StatusOK, upload, "")
+}
+
+// Patch appends the request body at the offset given in the Upload-Offset header
+func (auh *AssetUploadController) Patch(c echo.Context) error {
+	upload, err := auh.getUpload(c)
+	if err != nil {
+		return err
+	}
+
+	offset, err := strconv.ParseInt(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return helpers.Response(c, http.StatusBadRequest, nil, "Upload-Offset header must be a number")
+	}
+
+	chunk, err := io.ReadAll(io.LimitReader(c.Request().Body, min(upload.Size-offset, maxChunkSize)))
+	if err != nil {
+		return err
+	}
+
+	// The upload row stays locked while the chunk is stored, the offset is checked against it so
+	// chunks sent concurrently to any instance are taken in order
+	expiresAt := time.Now().Add(auh.cfg.AssetStorage.UploadExpiry)
+	current, err := auh.model.Append(c.Request().Context(), upload.ID, expiresAt, func(current structs.AssetUpload) (int64, error) {
+		if offset != current.Offset {
+			return 0, models.ErrUploadOffsetMismatch
+		}
+		chunk = chunk[:min(int64(len(chunk)), current.Size-current.Offset)]
+		if len(chunk) == 0 {
+			return 0, nil
+		}
+		return int64(len(chunk)), auh.uploadHelper.Put(current, chunk)
+	})
+	if errors.Is(err, models.ErrUploadOffsetMismatch) {
+		c.Response().Header().Set("Upload-Offset", strconv.FormatInt(current.Offset, 10))
+		return helpers.Response(c, http.StatusConflict, current, "Upload-Offset does not match the received bytes")
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Upload-Offset", strconv.FormatInt(current.Offset, 10))
+	return helpers.Response(c, http.StatusOK, current, "")
+}
+
+// Finalize moves a complete upload into the asset storage and returns the new asset
+func (auh *AssetUploadController) Finalize(c echo.Context) error {
+	upload, err := auh.getUpload(c)
+	if err != nil {
+		return err
+	}
+	if upload.Offset != upload.Size {
+		return helpers.Response(c, http.StatusConflict, upload, helpers.T(c, "Upload is incomplete, received {0} of {1} bytes",
+			strconv.FormatInt(upload.Offset, 10), strconv.FormatInt(upload.Size, 10)))
+	}
+
+	f, err := auh.uploadHelper.Open(*upload)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var staged *helpers.StagedContent
+	var url func(string) string
+	if imageHelper, ok := auh.imageHelpers[upload.Category]; ok {
+		staged, err = imageHelper.Stage(f)
+		url = imageHelper.URL
+	} else if fileHelper, ok := auh.fileHelpers[upload.Category]; ok {
+		staged, err = fileHelper.Stage(f, filepath.Ext(upload.Filename))
+		url = fileHelper.URL
+	} else {
+		// The category was dropped from the configuration since the upload started
+		return helpers.Response(c, http.StatusBadRequest, nil, "Unknown asset category")
+	}
+	if err != nil {
+		return err
+	}
+
+	asset, err := storeAsset(c.Request().Context(), auh.cfg, auh.assetModel, upload.Category, staged, upload.Filename)
+	if err != nil {
+		return err
+	}
+	asset.URL = url(asset.Path)
+
+	if err := auh.discard(c.Request().Context(), *upload); err != nil {
+		helpers.HandleError("Failed to discard finalized upload "+upload.ID.String(), err)
+	}
+
+	return helpers.Response(c, http.StatusCreated, asset, "")
+}
+
+func (auh *AssetUploadController) Delete(c echo.Context) error {
+	upload, err := auh.getUpload(c)
+	if err != nil {
+		return err
+	}
+	if err := auh.discard(c.Request().Context(), *upload); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Upload cancelled")
+}
+
+// getUpload loads the upload of the :id param, only its creator may use it until it expires
+func (auh *AssetUploadController) getUpload(c echo.Context) (*structs.AssetUpload, error) {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := auh.model.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := helpers.GetJWTUser(c)
+	if err != nil || upload.CreatedBy == nil || *upload.CreatedBy != claims.ID {
+		return nil, ErrUploadForbidden
+	}
+	if upload.ExpiresAt.Before(time.Now()) {
+		return nil, ErrUploadExpired
+	}
+	return &upload, nil
+}
+
+func (auh *AssetUploadController) discard(ctx context.Context, upload structs.AssetUpload) error {
+	if err := auh.uploadHelper.Remove(upload); err != nil {
+		return err
+	}
+	return auh.model.Delete(ctx, upload.ID)
+}
+
+func (auh *AssetUploadController) maxSize(category string) (int64, bool) {
+	if imageHelper, ok := auh.imageHelpers[category]; ok {
+		return imageHelper.MaxSize(), true
+	}
+	if fileHelper, ok := auh.fileHelpers[category]; ok {
+		return fileHelper.MaxSize(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type AuditLogController struct {
+	db    *gorm.DB
+	model *models.AuditLogModel
+	cfg   *config.Config
+}
+
+func NewAuditLogController(db *gorm.DB, model *models.AuditLogModel, cfg *config.Config) *AuditLogController {
+	return &AuditLogController{db, model, cfg}
+}
+
+// Index lists the audit log, newest first, filtered by filter[table_name]=m_user,
+// filter[row_id], filter[actor_id], filter[action] and the other fields of structs.AuditLogQuery
+func (alh *AuditLogController) Index(c echo.Context) error {
+	page := helpers.ParsePagination(c)
+
+	list, err := helpers.ParseListQuery(c, structs.AuditLogQuery)
+	if err != nil {
+		return err
+	}
+
+	data, err := alh.model.GetAll(&page, list)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, helpers.PageData(c, data, page), "")
+}
+
+func (alh *AuditLogController) GetById(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	data, err := alh.model.GetById(id)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "")
+}
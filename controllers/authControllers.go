@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type AuthController struct {
+	db        *gorm.DB
+	userModel *models.UserModel
+	roleModel *models.UserRoleModel
+	model     *models.AuthModel
+	cfg       *config.Config
+}
+
+func NewAuthController(db *gorm.DB, userModel *models.UserModel, roleModel *models.UserRoleModel, model *models.AuthModel, cfg *config.Config) *AuthController {
+	return &AuthController{db, userModel, roleModel, model, cfg}
+}
+
+// SignUp registers a user with the configured sign-up role, so clients can't pick their own permissions
+func (ah *AuthController) SignUp(c echo.Context) error {
+	var request structs.SignUpRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	role, err := ah.roleModel.GetByName(ah.cfg.Auth.SignUpRole)
+	if err != nil {
+		return err
+	}
+
+	data, err := ah.userModel.Create(c.Request().Context(), &structs.UserRequest{
+		Name:        request.Name,
+		Email:       request.Email,
+		PhoneNumber: request.PhoneNumber,
+		Language:    request.Language,
+		Password:    request.Password,
+		UserRolesId: role.ID.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusCreated, data, "")
+}
+
+func (ah *AuthController) Login(c echo.Context) error {
+	var request structs.LoginRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	user, err := ah.userModel.GetByEmail(request.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.Response(c, http.StatusUnauthorized, nil, "Invalid email or password")
+		}
+		return err
+	}
+
+	if !helpers.PasswordVerify(user.Password, request.Password) {
+		return helpers.Response(c, http.StatusUnauthorized, nil, "Invalid email or password")
+	}
+
+	if user.LockedAt != nil {
+		return helpers.Response(c, http.StatusForbidden, nil, "Account is locked")
+	}
+
+	data, err := ah.issueTokens(c.Request().Context(), user)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, data, "Login success")
+}
+
+func (ah *AuthController) Refresh(c echo.Context) error {
+	var request structs.RefreshTokenRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	refreshToken, err := ah.model.ConsumeRefreshToken(c.Request().Context(), helpers.HashToken(request.RefreshToken))
+	if err != nil {
+		return helpers.Response(c, http.StatusUnauthorized, nil, "Invalid or expired refresh token")
+	}
+
+	user, err := ah.userModel.GetById(refreshToken.UserID, structs.FieldSet{})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return helpers.Response(c, http.StatusUnauthorized, nil, "Invalid or expired refresh token")
+		}
+		return err
+	}
+
+	data, err := ah.issueTokens(c.Request().Context(), user)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, data, "Token refreshed")
+}
+
+func (ah *AuthController) Logout(c echo.Context) error {
+	var request structs.RefreshTokenRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	if err := ah.model.DeleteRefreshToken(c.Request().Context(), helpers.HashToken(request.RefreshToken)); err != nil {
+		return helpers.Response(c, http.StatusUnauthorized, nil, "Invalid or expired refresh token")
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Logout success")
+}
+
+// LogoutAll revokes every access and refresh token of the current user
+func (ah *AuthController) LogoutAll(c echo.Context) error {
+	claims, err := helpers.GetJWTUser(c)
+	if err != nil {
+		return helpers.Response(c, http.StatusUnauthorized, nil, err.Error())
+	}
+
+	if err := ah.userModel.BumpSecurity(c.Request().Context(), claims.ID); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Logged out from all devices")
+}
+
+func (ah *AuthController) issueTokens(ctx context.Context, user structs.User) (structs.TokenResponse, error) {
+	accessToken, err := helpers.GenerateAccessToken(user, ah.cfg.JWT.Secret, ah.cfg.JWT.AccessTokenTTL)
+	if err != nil {
+		return structs.TokenResponse{}, err
+	}
+
+	refreshToken, refreshTokenHash, err := helpers.GenerateRefreshToken()
+	if err != nil {
+		return structs.TokenResponse{}, err
+	}
+
+	if err := ah.model.CreateRefreshToken(ctx, user.ID, refreshTokenHash, time.Now().Add(ah.cfg.JWT.RefreshTokenTTL)); err != nil {
+		return structs.TokenResponse{}, err
+	}
+
+	user.Password = ""
+	return structs.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(ah.cfg.JWT.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type CustomerController struct {
+	db          *gorm.DB
+	model       *models.CustomerModel
+	cfg         *config.Config
+	imageHelper *helpers.ImageHelper
+}
+
+func NewCustomerController(db *gorm.DB, model *models.CustomerModel, cfg *config.Config, imageHelper *helpers.ImageHelper) *CustomerController {
+	return &CustomerController{db, model, cfg, imageHelper}
+}
+
+func (ch *CustomerController) Index(c echo.Context) error {
+	page := helpers.ParsePagination(c)
+
+	list, err := helpers.ParseListQuery(c, structs.CustomerQuery)
+	if err != nil {
+		return err
+	}
+	fields, err := helpers.ParseFieldSet(c, structs.CustomerQuery)
+	if err != nil {
+		return err
+	}
+
+	data, err := ch.model.GetAll(&page, c.QueryParam("search"), list, fields)
+	if err != nil {
+		return err
+	}
+	for i := range data {
+		ch.withPhotoUrl(&data[i])
+	}
+	pagedData := helpers.PageData(c, helpers.SparseFields(data, fields), page)
+	return helpers.Response(c, http.StatusOK, pagedData, "")
+}
+
+func (ch *CustomerController) GetById(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	fields, err := helpers.ParseFieldSet(c, structs.CustomerQuery)
+	if err != nil {
+		return err
+	}
+
+	data, err := ch.model.GetById(id, fields)
+	if err != nil {
+		return err
+	}
+	ch.withPhotoUrl(&data)
+	return helpers.Response(c, http.StatusOK, helpers.SparseFields(data, fields), "")
+}
+
+func (ch *CustomerController) Create(c echo.Context) error {
+	var request structs.CustomerRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := ch.model.Create(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+	ch.withPhotoUrl(&data)
+
+	return helpers.Response(c, http.StatusCreated, data, "")
+}
+
+func (ch *CustomerController) Update(c echo.Context) error {
+	var request structs.CustomerRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := ch.model.Update(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+	ch.withPhotoUrl(&data)
+
+	return helpers.Response(c, http.StatusOK, data, "Customer updated")
+}
+
+func (ch *CustomerController) Delete(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := ch.model.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Customer deleted")
+}
+
+// withPhotoUrl replaces the stored path of the photo with its URL on the active storage
+func (ch *CustomerController) withPhotoUrl(customer *structs.Customer) {
+	if customer.Photo != "" {
+		customer.Photo = ch.imageHelper.URL(customer.Photo)
+	}
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type ProductCategoryController struct {
+	db    *gorm.DB
+	model *models.ProductCategoryModel
+	cfg   *config.Config
+}
+
+func NewProductCategoryController(db *gorm.DB, model *models.ProductCategoryModel, cfg *config.Config) *ProductCategoryController {
+	return &ProductCategoryController{db, model, cfg}
+}
+
+func (pch *ProductCategoryController) Index(c echo.Context) error {
+	page := helpers.ParsePagination(c)
+
+	data, err := pch.model.GetAll(&page, c.QueryParam("search"))
+	if err != nil {
+		return err
+	}
+	pagedData := helpers.PageData(c, data, page)
+	return helpers.Response(c, http.StatusOK, pagedData, "")
+}
+
+func (pch *ProductCategoryController) GetById(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	data, err := pch.model.GetById(id)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "")
+}
+
+func (pch *ProductCategoryController) Create(c echo.Context) error {
+	var request structs.ProductCategoryRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := pch.model.Create(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusCreated, data, "")
+}
+
+func (pch *ProductCategoryController) Update(c echo.Context) error {
+	var request structs.ProductCategoryRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := pch.model.Update(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, data, "Product category updated")
+}
+
+func (pch *ProductCategoryController) Delete(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := pch.model.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Product category deleted")
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type ProductController struct {
+	db          *gorm.DB
+	model       *models.ProductModel
+	cfg         *config.Config
+	imageHelper *helpers.ImageHelper
+}
+
+func NewProductController(db *gorm.DB, model *models.ProductModel, cfg *config.Config, imageHelper *helpers.ImageHelper) *ProductController {
+	return &ProductController{db, model, cfg, imageHelper}
+}
+
+func (ph *ProductController) Index(c echo.Context) error {
+	page := helpers.ParsePagination(c)
+
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		return err
+	}
+	list, err := helpers.ParseListQuery(c, structs.ProductQuery)
+	if err != nil {
+		return err
+	}
+	fields, err := helpers.ParseFieldSet(c, structs.ProductQuery)
+	if err != nil {
+		return err
+	}
+
+	data, err := ph.model.GetAll(&page, filter, list, fields)
+	if err != nil {
+		return err
+	}
+	for i := range data {
+		ph.withImageUrl(&data[i])
+	}
+	pagedData := helpers.PageData(c, helpers.SparseFields(data, fields), page)
+	return helpers.Response(c, http.StatusOK, pagedData, "")
+}
+
+func (ph *ProductController) GetById(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	fields, err := helpers.ParseFieldSet(c, structs.ProductQuery)
+	if err != nil {
+		return err
+	}
+
+	data, err := ph.model.GetById(id, fields)
+	if err != nil {
+		return err
+	}
+	ph.withImageUrl(&data)
+	return helpers.Response(c, http.StatusOK, helpers.SparseFields(data, fields), "")
+}
+
+func (ph *ProductController) Create(c echo.Context) error {
+	var request structs.ProductRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := ph.model.Create(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+	ph.withImageUrl(&data)
+
+	return helpers.Response(c, http.StatusCreated, data, "")
+}
+
+func (ph *ProductController) Update(c echo.Context) error {
+	var request structs.ProductRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := ph.model.Update(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+	ph.withImageUrl(&data)
+
+	return helpers.Response(c, http.StatusOK, data, "Product updated")
+}
+
+func (ph *ProductController) Delete(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := ph.model.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Product deleted")
+}
+
+// withImageUrl replaces the stored path of the image with its URL on the active storage
+func (ph *ProductController) withImageUrl(product *structs.Product) {
+	if product.Image != "" {
+		product.Image = ph.imageHelper.URL(product.Image)
+	}
+}
+
+// parseProductFilter reads category_id, min_price, max_price and status (active/inactive) from the query
+func parseProductFilter(c echo.Context) (structs.ProductFilter, error) {
+	filter := structs.ProductFilter{Search: c.QueryParam("search")}
+
+	if categoryId := c.QueryParam("category_id"); categoryId != "" {
+		id, err := uuid.Parse(categoryId)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "category_id must be a valid UUID")
+		}
+		filter.ProductCategoryId = &id
+	}
+	if minPrice := c.QueryParam("min_price"); minPrice != "" {
+		price, err := strconv.ParseFloat(minPrice, 64)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "min_price must be a number")
+		}
+		filter.MinPrice = &price
+	}
+	if maxPrice := c.QueryParam("max_price"); maxPrice != "" {
+		price, err := strconv.ParseFloat(maxPrice, 64)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "max_price must be a number")
+		}
+		filter.MaxPrice = &price
+	}
+	switch c.QueryParam("status") {
+	case "":
+	case "active":
+		isActive := true
+		filter.IsActive = &isActive
+	case "inactive":
+		isActive := false
+		filter.IsActive = &isActive
+	default:
+		return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "status must be active or inactive")
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type ProductDetailController struct {
+	db    *gorm.DB
+	model *models.ProductDetailModel
+	cfg   *config.Config
+}
+
+func NewProductDetailController(db *gorm.DB, model *models.ProductDetailModel, cfg *config.Config) *ProductDetailController {
+	return &ProductDetailController{db, model, cfg}
+}
+
+func (pdh *ProductDetailController) Index(c echo.Context) error {
+	productId, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	data, err := pdh.model.GetByProduct(productId)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "")
+}
+
+func (pdh *ProductDetailController) Create(c echo.Context) error {
+	var request structs.ProductDetailRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	productId, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	request.ProductId = productId
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := pdh.model.Create(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusCreated, data, "")
+}
+
+func (pdh *ProductDetailController) Update(c echo.Context) error {
+	var request structs.ProductDetailRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	productId, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	request.ProductId = productId
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := pdh.model.Update(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, data, "Product detail updated")
+}
+
+func (pdh *ProductDetailController) Delete(c echo.Context) error {
+	productId, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	id, err := helpers.ParamUUID(c, "detailId")
+	if err != nil {
+		return err
+	}
+	if err := pdh.model.Delete(c.Request().Context(), productId, id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Product detail deleted")
+}
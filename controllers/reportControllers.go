@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type ReportController struct {
+	db    *gorm.DB
+	model *models.ReportModel
+	cfg   *config.Config
+}
+
+func NewReportController(db *gorm.DB, model *models.ReportModel, cfg *config.Config) *ReportController {
+	return &ReportController{db, model, cfg}
+}
+
+func (rh *ReportController) Sales(c echo.Context) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := rh.model.SalesSummary(filter)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "")
+}
+
+func (rh *ReportController) SalesDownload(c echo.Context) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := rh.model.SalesSummary(filter)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(data))
+	for _, row := range data {
+		rows = append(rows, []string{
+			row.Period,
+			strconv.Itoa(row.TotalTransactions),
+			formatMoney(row.Subtotal),
+			formatMoney(row.Discount),
+			formatMoney(row.Tax),
+			formatMoney(row.GrandTotal),
+		})
+	}
+	return helpers.CSVResponse(c, reportFilename("sales", filter),
+		[]string{"period", "total_transactions", "subtotal", "discount", "tax", "grand_total"}, rows)
+}
+
+func (rh *ReportController) TopProducts(c echo.Context) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := rh.model.TopProducts(filter)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "")
+}
+
+func (rh *ReportController) TopProductsDownload(c echo.Context) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := rh.model.TopProducts(filter)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(data))
+	for _, row := range data {
+		rows = append(rows, []string{
+			row.ProductId.String(),
+			row.Name,
+			row.SKU,
+			strconv.Itoa(row.Quantity),
+			formatMoney(row.Revenue),
+		})
+	}
+	return helpers.CSVResponse(c, reportFilename("top-products", filter),
+		[]string{"product_id", "name", "sku", "quantity", "revenue"}, rows)
+}
+
+func (rh *ReportController) Customers(c echo.Context) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := rh.model.CustomerRevenue(filter)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "")
+}
+
+func (rh *ReportController) CustomersDownload(c echo.Context) error {
+	filter, err := parseReportFilter(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := rh.model.CustomerRevenue(filter)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(data))
+	for _, row := range data {
+		rows = append(rows, []string{
+			row.CustomerId.String(),
+			row.Name,
+			strconv.Itoa(row.TotalTransactions),
+			formatMoney(row.Revenue),
+		})
+	}
+	return helpers.CSVResponse(c, reportFilename("customers", filter),
+		[]string{"customer_id", "name", "total_transactions", "revenue"}, rows)
+}
+
+// parseReportFilter defaults to the last 30 days, grouped by day, limited to 10 rows
+func parseReportFilter(c echo.Context) (structs.ReportFilter, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	filter := structs.ReportFilter{
+		StartDate: today.AddDate(0, 0, -30),
+		EndDate:   today,
+		GroupBy:   "day",
+		OrderBy:   "quantity",
+		Limit:     10,
+	}
+
+	if startDate := c.QueryParam("start_date"); startDate != "" {
+		date, err := time.ParseInLocation(time.DateOnly, startDate, time.Local)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "start_date must use the YYYY-MM-DD format")
+		}
+		filter.StartDate = date
+	}
+	if endDate := c.QueryParam("end_date"); endDate != "" {
+		date, err := time.ParseInLocation(time.DateOnly, endDate, time.Local)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "end_date must use the YYYY-MM-DD format")
+		}
+		filter.EndDate = date
+	}
+	if filter.EndDate.Before(filter.StartDate) {
+		return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "end_date must not be before start_date")
+	}
+
+	switch groupBy := c.QueryParam("group_by"); groupBy {
+	case "":
+	case "day", "week", "month":
+		filter.GroupBy = groupBy
+	default:
+		return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "group_by must be day, week or month")
+	}
+
+	switch orderBy := c.QueryParam("order_by"); orderBy {
+	case "":
+	case "quantity", "revenue":
+		filter.OrderBy = orderBy
+	default:
+		return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "order_by must be quantity or revenue")
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		intLimit, err := strconv.Atoi(limit)
+		if err != nil || intLimit <= 0 || intLimit > 100 {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "limit must be a number between 1 and 100")
+		}
+		filter.Limit = intLimit
+	}
+
+	return filter, nil
+}
+
+func reportFilename(name string, filter structs.ReportFilter) string {
+	return fmt.Sprintf("%s_%s_%s.csv", name, filter.StartDate.Format(time.DateOnly), filter.EndDate.Format(time.DateOnly))
+}
+
+func formatMoney(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
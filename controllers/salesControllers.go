@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type SalesController struct {
+	db    *gorm.DB
+	model *models.SalesModel
+	cfg   *config.Config
+}
+
+func NewSalesController(db *gorm.DB, model *models.SalesModel, cfg *config.Config) *SalesController {
+	return &SalesController{db, model, cfg}
+}
+
+func (sh *SalesController) Index(c echo.Context) error {
+	page := helpers.ParsePagination(c)
+
+	filter, err := parseSaleFilter(c)
+	if err != nil {
+		return err
+	}
+	list, err := helpers.ParseListQuery(c, structs.SaleQuery)
+	if err != nil {
+		return err
+	}
+	fields, err := helpers.ParseFieldSet(c, structs.SaleQuery)
+	if err != nil {
+		return err
+	}
+
+	data, err := sh.model.GetAll(&page, filter, list, fields)
+	if err != nil {
+		return err
+	}
+	pagedData := helpers.PageData(c, helpers.SparseFields(data, fields), page)
+	return helpers.Response(c, http.StatusOK, pagedData, "")
+}
+
+func (sh *SalesController) GetById(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	fields, err := helpers.ParseFieldSet(c, structs.SaleQuery)
+	if err != nil {
+		return err
+	}
+
+	data, err := sh.model.GetById(id, fields)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, helpers.SparseFields(data, fields), "")
+}
+
+func (sh *SalesController) Create(c echo.Context) error {
+	var request structs.SaleRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	data, err := sh.model.Create(c.Request().Context(), &request, sh.cfg.Sales.TaxRate)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusCreated, data, "")
+}
+
+func (sh *SalesController) Delete(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := sh.model.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Sale deleted")
+}
+
+// parseSaleFilter reads customer_id, start_date and end_date (YYYY-MM-DD) from the query
+func parseSaleFilter(c echo.Context) (structs.SaleFilter, error) {
+	filter := structs.SaleFilter{Search: c.QueryParam("search")}
+
+	if customerId := c.QueryParam("customer_id"); customerId != "" {
+		id, err := uuid.Parse(customerId)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "customer_id must be a valid UUID")
+		}
+		filter.CustomerId = &id
+	}
+	if startDate := c.QueryParam("start_date"); startDate != "" {
+		date, err := time.ParseInLocation(time.DateOnly, startDate, time.Local)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "start_date must use the YYYY-MM-DD format")
+		}
+		filter.StartDate = &date
+	}
+	if endDate := c.QueryParam("end_date"); endDate != "" {
+		date, err := time.ParseInLocation(time.DateOnly, endDate, time.Local)
+		if err != nil {
+			return filter, apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "end_date must use the YYYY-MM-DD format")
+		}
+		filter.EndDate = &date
+	}
+
+	return filter, nil
+}
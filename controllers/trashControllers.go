@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TrashController serves the trash of any module, see models.TrashModel
+type TrashController[T structs.Tabler] struct {
+	model *models.TrashModel[T]
+}
+
+func NewTrashController[T structs.Tabler](model *models.TrashModel[T]) *TrashController[T] {
+	return &TrashController[T]{model}
+}
+
+func (th *TrashController[T]) Index(c echo.Context) error {
+	page := helpers.ParsePagination(c)
+
+	list, err := helpers.ParseListQuery(c, th.model.Schema())
+	if err != nil {
+		return err
+	}
+	fields, err := helpers.ParseFieldSet(c, th.model.Schema())
+	if err != nil {
+		return err
+	}
+
+	data, err := th.model.GetAll(&page, list, fields)
+	if err != nil {
+		return err
+	}
+	pagedData := helpers.PageData(c, helpers.SparseFields(data, fields), page)
+	return helpers.Response(c, http.StatusOK, pagedData, "")
+}
+
+func (th *TrashController[T]) Restore(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	data, err := th.model.Restore(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "Restored")
+}
+
+// Purge erases the row for good and releases its assets
+func (th *TrashController[T]) Purge(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := th.model.Purge(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Permanently deleted")
+}
@@ -2,131 +2,294 @@ package controllers
 
 import (
 	"errors"
-	"fmt"
-	"log"
 	"net/http"
+	"simple-crud-rnd/apperrors"
 	"simple-crud-rnd/config"
 	"simple-crud-rnd/helpers"
 	"simple-crud-rnd/models"
 	"simple-crud-rnd/structs"
-	"strconv"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrRoleNotFound      = apperrors.New(http.StatusBadRequest, "role_not_found", "role not found")
+	ErrRoleNotAssignable = apperrors.New(http.StatusForbidden, "role_not_assignable", "The Super Admin role can only be given with users:create-admin")
+)
+
 type UserController struct {
 	db          *gorm.DB
 	model       *models.UserModel
+	roleModel   *models.UserRoleModel
+	assetModel  *models.AssetModel
 	cfg         *config.Config
 	imageHelper *helpers.ImageHelper
-	assetPath   string
 }
 
-func NewUserController(db *gorm.DB, model *models.UserModel, cfg *config.Config, imageHelper *helpers.ImageHelper, assetPath string) *UserController {
-	return &UserController{db, model, cfg, imageHelper, assetPath}
+func NewUserController(db *gorm.DB, model *models.UserModel, roleModel *models.UserRoleModel, assetModel *models.AssetModel, cfg *config.Config, imageHelper *helpers.ImageHelper) *UserController {
+	return &UserController{db, model, roleModel, assetModel, cfg, imageHelper}
 }
 
 func (uh *UserController) Index(c echo.Context) error {
-	per_page, err := strconv.Atoi(c.QueryParam("per_page"))
+	page := helpers.ParsePagination(c)
+
+	list, err := helpers.ParseListQuery(c, structs.UserQuery)
 	if err != nil {
-		per_page = 10
-		log.Printf("Failed to parse per_page query parameter. Defaulting to %d", per_page)
+		return err
 	}
-	page, err := strconv.Atoi(c.QueryParam("page"))
+	fields, err := helpers.ParseFieldSet(c, structs.UserQuery)
 	if err != nil {
-		page = 1
-		log.Printf("Failed to parse page query parameter. Defaulting to %d", page)
+		return err
 	}
 
-	offset := (page - 1) * per_page
-	data, total, err := uh.model.GetAll(per_page, offset)
+	data, err := uh.model.GetAll(&page, list, fields)
 	if err != nil {
-		return helpers.Response(c, http.StatusInternalServerError, data, err.Error())
+		return err
+	}
+	users := make([]*structs.User, len(data))
+	for i := range data {
+		users[i] = &data[i]
+	}
+	if err := uh.withPhotoUrls(users...); err != nil {
+		return err
 	}
-	pagedData := helpers.PageData(data, total)
+	pagedData := helpers.PageData(c, helpers.SparseFields(data, fields), page)
 	return helpers.Response(c, http.StatusOK, pagedData, "")
 }
 
 func (uh *UserController) GetById(c echo.Context) error {
-	id, err := uuid.Parse(c.Param("id"))
+	id, err := helpers.ParamUUID(c, "id")
 	if err != nil {
 		return err
 	}
 
-	data, err := uh.model.GetById(id)
+	fields, err := helpers.ParseFieldSet(c, structs.UserQuery)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return helpers.Response(c, http.StatusNotFound, nil, err.Error())
-		}
-		return helpers.Response(c, http.StatusInternalServerError, nil, err.Error())
+		return err
+	}
+
+	data, err := uh.model.GetById(id, fields)
+	if err != nil {
+		return err
+	}
+	if err := uh.withPhotoUrls(&data); err != nil {
+		return err
 	}
-	return helpers.Response(c, http.StatusOK, data, "")
+	return helpers.Response(c, http.StatusOK, helpers.SparseFields(data, fields), "")
 }
 
 func (uh *UserController) Create(c echo.Context) error {
 	var request structs.UserRequest
 
 	if err := c.Bind(&request); err != nil {
-		return helpers.Response(c, http.StatusBadRequest, nil, err.Error())
+		return err
 	}
 
 	if err := c.Validate(request); err != nil {
-		return helpers.Response(c, http.StatusBadRequest, nil, err.Error())
+		return err
 	}
 
-	if request.Photo != "" {
-		photo_url, err := uh.imageHelper.Writer(request.Photo, fmt.Sprintf("%s.png", time.Now().Format("20061021545.000000000")))
-		if err != nil {
-			return helpers.Response(c, http.StatusInternalServerError, nil, err.Error())
-		}
-		request.Photo = photo_url
+	if err := uh.checkRole(request.UserRolesId); err != nil {
+		return err
 	}
 
-	data, err := uh.model.Create(&request)
+	data, err := uh.model.Create(c.Request().Context(), &request)
 	if err != nil {
-		return helpers.Response(c, http.StatusInternalServerError, nil, "")
+		return err
+	}
+	if err := uh.withPhotoUrls(&data); err != nil {
+		return err
 	}
+	data.Password = ""
 
 	return helpers.Response(c, http.StatusCreated, data, "")
 }
 
 func (uh *UserController) Update(c echo.Context) error {
-	var request structs.User
+	var request structs.UserUpdateRequest
 
 	if err := c.Bind(&request); err != nil {
-		return helpers.Response(c, http.StatusBadRequest, nil, err.Error())
+		return err
 	}
 
 	if err := c.Validate(request); err != nil {
-		return helpers.Response(c, http.StatusBadRequest, nil, err.Error())
+		return err
 	}
 
-	if request.Photo != "" {
-		photo_url, err := uh.imageHelper.Writer(request.Photo, fmt.Sprintf("%s.png", time.Now().Format("20061021545.000000000")))
-		if err != nil {
-			return helpers.Response(c, http.StatusInternalServerError, nil, err.Error())
-		}
-		request.Photo = photo_url
-	}
-	data, err := uh.model.Update(request)
+	data, err := uh.model.Update(c.Request().Context(), &request)
 	if err != nil {
-		return helpers.Response(c, http.StatusInternalServerError, nil, err.Error())
+		return err
+	}
+	if err := uh.withPhotoUrls(&data); err != nil {
+		return err
 	}
+	data.Password = ""
 
 	return helpers.Response(c, http.StatusOK, data, "User updated")
 }
 
+// SetRole moves the user to another role, a permission of its own since it decides what the user may do
+func (uh *UserController) SetRole(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var request structs.SetRoleRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	if err := uh.checkRole(request.UserRolesId); err != nil {
+		return err
+	}
+
+	if err := uh.model.SetRole(c.Request().Context(), id, uuid.MustParse(request.UserRolesId)); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Role assigned")
+}
+
 func (uh *UserController) Delete(c echo.Context) error {
-	id, err := uuid.Parse(c.Param("id"))
+	id, err := helpers.ParamUUID(c, "id")
 	if err != nil {
 		return err
 	}
-	if err := uh.model.Delete(id); err != nil {
-		return helpers.Response(c, http.StatusInternalServerError, nil, err.Error())
+	if err := uh.model.Delete(c.Request().Context(), id); err != nil {
+		return err
 	}
 
 	return helpers.Response(c, http.StatusOK, true, "User deleted")
 }
+
+func (uh *UserController) ChangePassword(c echo.Context) error {
+	var request structs.ChangePasswordRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	claims, err := helpers.GetJWTUser(c)
+	if err != nil {
+		return helpers.Response(c, http.StatusUnauthorized, nil, err.Error())
+	}
+
+	if err := uh.model.ChangePassword(c.Request().Context(), claims.ID, request.OldPassword, request.NewPassword); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Password changed, please login again")
+}
+
+// ChangeLanguage saves the language of the authenticated user, it wins over Accept-Language
+func (uh *UserController) ChangeLanguage(c echo.Context) error {
+	var request structs.LanguageRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	claims, err := helpers.GetJWTUser(c)
+	if err != nil {
+		return helpers.Response(c, http.StatusUnauthorized, nil, err.Error())
+	}
+
+	if err := uh.model.SetLanguage(c.Request().Context(), claims.ID, request.Language); err != nil {
+		return err
+	}
+	if request.Language != "" {
+		helpers.UseLanguage(c, request.Language)
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Language updated")
+}
+
+func (uh *UserController) Lock(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := uh.model.Lock(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "User locked")
+}
+
+func (uh *UserController) Unlock(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := uh.model.Unlock(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "User unlocked")
+}
+
+// checkRole refuses roles that do not exist and the super admin role, which would hand out every
+// permission to whoever may create or update users
+func (uh *UserController) checkRole(roleId string) error {
+	id, err := uuid.Parse(roleId)
+	if err != nil {
+		return ErrRoleNotFound
+	}
+	role, err := uh.roleModel.GetById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if role.Name == structs.SuperAdminRole {
+		return ErrRoleNotAssignable
+	}
+	return nil
+}
+
+// withPhotoUrls exposes the profile photos and their resized variants as URLs of the active
+// storage. Only the sizes recorded on the photo's asset are listed, those are the files that exist.
+func (uh *UserController) withPhotoUrls(users ...*structs.User) error {
+	paths := []string{}
+	for _, user := range users {
+		if user.Photo != "" {
+			paths = append(paths, user.Photo)
+		}
+	}
+	variants, err := uh.assetModel.GetVariants(uh.imageHelper.Category(), paths)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		variantPaths := uh.imageHelper.VariantPaths(user.Photo, variants[user.Photo])
+		if user.Photo != "" {
+			user.Photo = uh.imageHelper.URL(user.Photo)
+		}
+		if variantPaths == nil {
+			continue
+		}
+		user.PhotoUrls = map[string]string{}
+		for size, path := range variantPaths {
+			user.PhotoUrls[size] = uh.imageHelper.URL(path)
+		}
+	}
+	return nil
+}
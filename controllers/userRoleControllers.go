@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"net/http"
+	"simple-crud-rnd/config"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type UserRoleController struct {
+	db    *gorm.DB
+	model *models.UserRoleModel
+	cfg   *config.Config
+}
+
+func NewUserRoleController(db *gorm.DB, model *models.UserRoleModel, cfg *config.Config) *UserRoleController {
+	return &UserRoleController{db, model, cfg}
+}
+
+func (rh *UserRoleController) Index(c echo.Context) error {
+	page := helpers.ParsePagination(c)
+
+	data, err := rh.model.GetAll(&page, c.QueryParam("search"))
+	if err != nil {
+		return err
+	}
+	pagedData := helpers.PageData(c, data, page)
+	return helpers.Response(c, http.StatusOK, pagedData, "")
+}
+
+func (rh *UserRoleController) Permissions(c echo.Context) error {
+	return helpers.Response(c, http.StatusOK, structs.AvailablePermissions, "")
+}
+
+func (rh *UserRoleController) GetById(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	data, err := rh.model.GetById(id)
+	if err != nil {
+		return err
+	}
+	return helpers.Response(c, http.StatusOK, data, "")
+}
+
+func (rh *UserRoleController) Create(c echo.Context) error {
+	var request structs.UserRoleRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	if permission, ok := unknownPermission(request.Permissions); ok {
+		return helpers.Response(c, http.StatusBadRequest, nil, helpers.T(c, "Unknown permission {0}", permission))
+	}
+
+	data, err := rh.model.Create(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusCreated, data, "")
+}
+
+func (rh *UserRoleController) Update(c echo.Context) error {
+	var request structs.UserRoleRequest
+
+	if err := c.Bind(&request); err != nil {
+		return err
+	}
+
+	if err := c.Validate(request); err != nil {
+		return err
+	}
+
+	if permission, ok := unknownPermission(request.Permissions); ok {
+		return helpers.Response(c, http.StatusBadRequest, nil, helpers.T(c, "Unknown permission {0}", permission))
+	}
+
+	data, err := rh.model.Update(c.Request().Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, data, "Role updated")
+}
+
+func (rh *UserRoleController) Delete(c echo.Context) error {
+	id, err := helpers.ParamUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := rh.model.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return helpers.Response(c, http.StatusOK, true, "Role deleted")
+}
+
+// unknownPermission returns the first permission that is not in structs.AvailablePermissions.
+// structs.PermissionAll is not one of them, only the seeded super admin role holds it.
+func unknownPermission(permissions []string) (string, bool) {
+	for _, permission := range permissions {
+		if !slices.Contains(structs.AvailablePermissions, permission) {
+			return permission, true
+		}
+	}
+	return "", false
+}
@@ -1,88 +1,256 @@
 package helpers
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-	"os"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path/filepath"
+	"simple-crud-rnd/apperrors"
+	"slices"
+	"strconv"
 	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrInvalidImage matches every rejection caused by the uploaded content, the others share its code
+// to tell the client why
+var (
+	ErrInvalidImage       = apperrors.New(http.StatusBadRequest, "invalid_image", "invalid image")
+	errImageTooLarge      = apperrors.New(http.StatusBadRequest, "invalid_image", "Image is larger than {0} bytes")
+	errImageType          = apperrors.New(http.StatusBadRequest, "invalid_image", "{0} is not a supported image type, use PNG, JPEG, WebP or GIF")
+	errImageTooManyPixels = apperrors.New(http.StatusBadRequest, "invalid_image", "Image is {0}x{1} pixels, the limit is {2}x{3}")
 )
 
+// imageExtensions lists the accepted content types and the extension they are saved with
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
 type (
 	ImageHelper struct {
-		fullPath    string
-		storagePath string
-		category    string
+		storage  Storage
+		category string
+		options  ImageOptions
 	}
-)
 
-func NewImageHelper(storagePath, category string) (*ImageHelper, error) {
-	fullPath := fmt.Sprintf("%s/%s/%s", storagePath, "images", category)
-	if err := os.MkdirAll(fullPath, os.ModePerm); err != nil {
-		return &ImageHelper{}, nil
+	ImageOptions struct {
+		MaxSize   int64
+		MaxWidth  int
+		MaxHeight int
+		// Variants are the sizes, in pixels of the longest side, of the resized copies made on upload
+		Variants []int
 	}
-	return &ImageHelper{fullPath, storagePath, category}, nil
+)
+
+func NewImageHelper(storage Storage, category string, options ImageOptions) *ImageHelper {
+	return &ImageHelper{storage, category, options}
 }
 
-func (img *ImageHelper) Writer(imageString string, filename string) (string, error) {
-	// Check if imageString contains the MIME type prefix
-	if strings.Contains(imageString, "base64,") {
-		// Remove the MIME type prefix
-		parts := strings.Split(imageString, "base64,")
-		if len(parts) > 1 {
-			imageString = parts[1] // Get the actual Base64 string
-		}
+// Writer validates the upload and saves it, see Stage
+func (img *ImageHelper) Writer(src io.Reader) (string, error) {
+	staged, err := img.Stage(src)
+	if err != nil {
+		return "", err
+	}
+	if err := staged.Write(); err != nil {
+		return "", err
 	}
+	return staged.Path, nil
+}
 
-	// Decode the Base64 string
-	dec, err := base64.StdEncoding.DecodeString(imageString)
+// Stage validates the upload and names it by the SHA-256 of its content plus the extension of its
+// real content type, so identical uploads share one file. Nothing is stored until Write is called.
+func (img *ImageHelper) Stage(src io.Reader) (*StagedContent, error) {
+	// Read at most one byte over the limit to detect oversized uploads
+	data, err := io.ReadAll(io.LimitReader(src, img.options.MaxSize+1))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Create the file
-	f, err := os.Create(fmt.Sprintf("%s/%s", img.fullPath, filename))
+	ext, err := img.validate(data)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer f.Close()
 
-	// Write the decoded data to the file
-	if _, err := f.Write(dec); err != nil {
-		return "", err
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := fmt.Sprintf("%s/%s/%s%s", "images", img.category, hash, ext)
+	contentType := http.DetectContentType(data)
+
+	return &StagedContent{
+		Hash:        hash,
+		Path:        path,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Variants:    slices.Clone(img.options.Variants),
+		write: func() error {
+			// Store the validated data
+			if err := putBytes(img.storage, path, data, contentType); err != nil {
+				return err
+			}
+			return img.writeVariants(data, path)
+		},
+	}, nil
+}
+
+// VariantPaths returns the path of every resized copy of an image, keyed by size. Pass the sizes
+// recorded on its asset, the configured sizes may have changed since the image was written.
+func (img *ImageHelper) VariantPaths(path string, sizes []int) map[string]string {
+	if path == "" || len(sizes) == 0 {
+		return nil
 	}
-	if err := f.Sync(); err != nil {
-		return "", err
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	paths := map[string]string{}
+	for _, size := range sizes {
+		paths[strconv.Itoa(size)] = fmt.Sprintf("%s_%d%s", base, size, variantExtension(ext))
+	}
+	return paths
+}
+
+// Delete removes an image together with its variants of the given sizes, missing files are ignored
+func (img *ImageHelper) Delete(path string, sizes []int) error {
+	paths := []string{path}
+	for _, variant := range img.VariantPaths(path, sizes) {
+		paths = append(paths, variant)
 	}
 
-	// Return the file path
-	return fmt.Sprintf("%s/%s/%s", "images", img.category, filename), nil
+	for _, p := range paths {
+		if err := img.storage.Delete(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// URL returns the address clients use to download the image from the active storage
+func (img *ImageHelper) URL(path string) string {
+	return img.storage.URL(path)
+}
+
+func (img *ImageHelper) Category() string {
+	return img.category
+}
+
+func (img *ImageHelper) MaxSize() int64 {
+	return img.options.MaxSize
 }
 
-func (img *ImageHelper) Read(filepath string) (string, error) {
-	// Open the file
-	f, err := os.Open(filepath)
+func (img *ImageHelper) Read(path string) (string, error) {
+	// Read the file contents
+	f, err := img.storage.Get(path)
 	if err != nil {
 		return "", err
 	}
 	defer f.Close()
 
-	// Read the file contents
-	fileInfo, err := f.Stat()
+	fileBytes, err := io.ReadAll(f)
 	if err != nil {
 		return "", err
 	}
 
-	fileSize := fileInfo.Size()
-	fileBytes := make([]byte, fileSize)
+	// Convert the file content to Base64 string
+	encodedString := base64.StdEncoding.EncodeToString(fileBytes)
 
-	_, err = f.Read(fileBytes)
+	// return the MIME type prefix for the detected image format
+	return fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(fileBytes), encodedString), nil
+}
+
+// validate sniffs the content type and checks the size and pixel dimensions against the limits
+func (img *ImageHelper) validate(data []byte) (string, error) {
+	if int64(len(data)) > img.options.MaxSize {
+		return "", errImageTooLarge.With(strconv.FormatInt(img.options.MaxSize, 10))
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return "", errImageType.With(contentType)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%w: %s content can not be decoded", ErrInvalidImage, contentType)
+	}
+	if cfg.Width > img.options.MaxWidth || cfg.Height > img.options.MaxHeight {
+		return "", errImageTooManyPixels.With(strconv.Itoa(cfg.Width), strconv.Itoa(cfg.Height),
+			strconv.Itoa(img.options.MaxWidth), strconv.Itoa(img.options.MaxHeight))
 	}
 
-	// Convert the file content to Base64 string
-	encodedString := base64.StdEncoding.EncodeToString(fileBytes)
+	return ext, nil
+}
+
+// writeVariants re-encodes a downscaled copy for every configured size. Images that are already
+// smaller than a size are re-encoded as they are, so every variant always exists.
+func (img *ImageHelper) writeVariants(data []byte, path string) error {
+	if len(img.options.Variants) == 0 {
+		return nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: content can not be decoded", ErrInvalidImage)
+	}
+
+	variantPaths := img.VariantPaths(path, img.options.Variants)
+	for _, size := range img.options.Variants {
+		var buf bytes.Buffer
+		variantPath := variantPaths[strconv.Itoa(size)]
+		resized := resizeImage(src, size)
+		if filepath.Ext(variantPath) == ".jpg" {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, resized)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := putBytes(img.storage, variantPath, buf.Bytes(), http.DetectContentType(buf.Bytes())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// variantExtension keeps JPEG as JPEG and stores everything else as PNG so transparency survives
+func variantExtension(ext string) string {
+	if ext == ".jpg" {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+func resizeImage(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return src
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
 
-	// return the MIME type prefix for image format
-	return fmt.Sprintf("data:image/png;base64,%s", encodedString), nil
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
 }
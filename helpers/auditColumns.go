@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AuditColumns is a GORM plugin filling created_by, updated_by and deleted_by, on every model
+// having them, with the user found by ActorFrom in the context of the query. A value already on the
+// row is overwritten, it may come from a request body. Queries without a user, such as sign ups and
+// commands, leave them alone.
+type AuditColumns struct{}
+
+func (AuditColumns) Name() string {
+	return "audit_columns"
+}
+
+func (AuditColumns) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("audit_columns:create", auditCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("audit_columns:update", auditUpdate); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("audit_columns:delete", auditDelete)
+}
+
+func auditCreate(db *gorm.DB) {
+	actor, ok := auditActor(db)
+	if !ok {
+		return
+	}
+
+	for _, column := range []string{"created_by", "updated_by"} {
+		field := db.Statement.Schema.LookUpField(column)
+		if field == nil {
+			continue
+		}
+		eachRow(db.Statement.ReflectValue, func(row reflect.Value) {
+			db.AddError(field.Set(db.Statement.Context, row, &actor))
+		})
+	}
+}
+
+func auditUpdate(db *gorm.DB) {
+	actor, ok := auditActor(db)
+	if !ok || db.Statement.Schema.LookUpField("updated_by") == nil {
+		return
+	}
+
+	db.Statement.SetColumn("updated_by", &actor, true)
+	// An update limited by Select would skip the column otherwise
+	if selects := db.Statement.Selects; len(selects) > 0 && selects[0] != "*" {
+		// Copied, appending in place could write into the array of the caller's statement
+		db.Statement.Selects = append(slices.Clone(selects), "updated_by")
+	}
+}
+
+// auditDelete adds deleted_by to the UPDATE of a soft delete. GORM builds that statement inside
+// the gorm:delete callback and replaces the assignments of the SET clause while doing so, which
+// is why the column is appended after them instead of being assigned here.
+func auditDelete(db *gorm.DB) {
+	actor, ok := auditActor(db)
+	if !ok || db.Statement.Unscoped || len(db.Statement.Schema.DeleteClauses) == 0 || db.Statement.Schema.LookUpField("deleted_by") == nil {
+		return
+	}
+
+	set := db.Statement.Clauses["SET"]
+	set.AfterExpression = clause.Expr{SQL: ", ? = ?", Vars: []interface{}{clause.Column{Name: "deleted_by"}, actor}}
+	db.Statement.Clauses["SET"] = set
+}
+
+func auditActor(db *gorm.DB) (uuid.UUID, bool) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return uuid.UUID{}, false
+	}
+	return ActorFrom(db.Statement.Context)
+}
+
+func eachRow(value reflect.Value, fn func(reflect.Value)) {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if row := reflect.Indirect(value.Index(i)); row.Kind() == reflect.Struct {
+				fn(row)
+			}
+		}
+	case reflect.Struct:
+		fn(value)
+	}
+}
@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"context"
+	"simple-crud-rnd/structs"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func auditColumnsDB(t *testing.T, actor uuid.UUID) *gorm.DB {
+	t.Helper()
+	db := dryRunDB(t)
+	if err := db.Use(AuditColumns{}); err != nil {
+		t.Fatal(err)
+	}
+	return db.WithContext(WithActor(context.Background(), actor))
+}
+
+func TestAuditColumnsOverwriteAuthor(t *testing.T) {
+	actor := uuid.New()
+	forged := uuid.New()
+	product := structs.Product{Name: "tea", CreatedBy: &forged, UpdatedBy: &forged}
+	if err := auditColumnsDB(t, actor).Create(&product).Error; err != nil {
+		t.Fatal(err)
+	}
+	if *product.CreatedBy != actor || *product.UpdatedBy != actor {
+		t.Errorf("created_by %s and updated_by %s, want the actor %s", product.CreatedBy, product.UpdatedBy, actor)
+	}
+}
+
+func TestAuditColumnsKeepCallerSelects(t *testing.T) {
+	columns := make([]string, 2, 3)
+	columns[0], columns[1] = "name", "price"
+
+	product := structs.Product{ID: uuid.New(), Name: "tea"}
+	stmt := auditColumnsDB(t, uuid.New()).Select(columns).Updates(&product).Statement
+	if !strings.Contains(stmt.SQL.String(), "`updated_by`=?") {
+		t.Errorf("updated_by is not set by %s", stmt.SQL.String())
+	}
+	if spare := columns[:3][2]; spare != "" {
+		t.Errorf("the caller's select array was written to, holds %q", spare)
+	}
+}
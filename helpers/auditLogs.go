@@ -0,0 +1,293 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"simple-crud-rnd/structs"
+	"slices"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type requestInfoKey struct{}
+
+// RequestInfo identifies the request a query runs for in the audit log
+type RequestInfo struct {
+	IP        string
+	RequestId string
+}
+
+// WithRequestInfo returns a copy of ctx carrying the client IP and the request ID
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the request stored by WithRequestInfo, empty outside of a request
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// auditMasked are the columns whose values never reach the audit log, only the fact they changed
+var auditMasked = []string{"password"}
+
+// auditIgnored change on every update, they add nothing to what the entry itself records
+var auditIgnored = []string{"updated_at", "updated_by"}
+
+const auditSnapshotKey = "audit_logs:snapshot"
+
+// AuditLogs is a GORM plugin writing a structs.AuditLog for every row of Tables created, updated or
+// deleted, in the transaction of the change. The actor comes from ActorFrom and the IP and request
+// ID from RequestInfoFrom. Updates and deletes read the affected rows first to know the old values.
+//
+// Only model queries are seen, raw SQL through Exec is not logged.
+type AuditLogs struct {
+	Tables []string
+}
+
+func (AuditLogs) Name() string {
+	return "audit_logs"
+}
+
+func (al AuditLogs) Initialize(db *gorm.DB) error {
+	create := db.Callback().Create()
+	if err := create.After("gorm:create").Before("gorm:commit_or_rollback_transaction").Register("audit_logs:create", al.afterCreate); err != nil {
+		return err
+	}
+
+	update := db.Callback().Update()
+	if err := update.Before("gorm:update").Register("audit_logs:before_update", al.snapshot); err != nil {
+		return err
+	}
+	if err := update.After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register("audit_logs:update", al.afterUpdate); err != nil {
+		return err
+	}
+
+	remove := db.Callback().Delete()
+	if err := remove.Before("gorm:delete").Register("audit_logs:before_delete", al.snapshot); err != nil {
+		return err
+	}
+	return remove.After("gorm:delete").Before("gorm:commit_or_rollback_transaction").Register("audit_logs:delete", al.afterDelete)
+}
+
+func (al AuditLogs) audited(db *gorm.DB) bool {
+	return db.Error == nil && db.Statement.Schema != nil && slices.Contains(al.Tables, db.Statement.Table)
+}
+
+func (al AuditLogs) afterCreate(db *gorm.DB) {
+	if !al.audited(db) {
+		return
+	}
+
+	entries := []structs.AuditLog{}
+	eachRow(db.Statement.ReflectValue, func(row reflect.Value) {
+		values := map[string]interface{}{}
+		for _, field := range db.Statement.Schema.Fields {
+			if field.DBName != "" {
+				values[field.DBName], _ = field.ValueOf(db.Statement.Context, row)
+			}
+		}
+		entries = append(entries, auditEntry(db, structs.AuditCreate, values, auditDiff(nil, values)))
+	})
+	writeAuditLogs(db, entries)
+}
+
+// snapshot reads the rows an update or delete is about to change, through its conditions and the
+// primary key of its model
+func (al AuditLogs) snapshot(db *gorm.DB) {
+	if !al.audited(db) {
+		return
+	}
+
+	where, conditioned := db.Statement.Clauses["WHERE"].Expression.(clause.Where)
+	keys := auditKeys(db)
+	// GORM refuses the statement itself without conditions
+	if !conditioned && len(keys) == 0 {
+		return
+	}
+
+	query := auditQuery(db)
+	if conditioned {
+		query = query.Clauses(where)
+	}
+	if len(keys) > 0 {
+		query = query.Where(clause.IN{Column: clause.PrimaryColumn, Values: keys})
+	}
+	if db.Statement.Unscoped {
+		query = query.Unscoped()
+	}
+
+	rows := []map[string]interface{}{}
+	if err := query.Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(auditSnapshotKey, rows)
+}
+
+func (al AuditLogs) afterUpdate(db *gorm.DB) {
+	old, ok := auditSnapshot(db)
+	if !ok || !al.audited(db) || len(old) == 0 {
+		return
+	}
+
+	// The update may have changed the columns the rows were found by, they are read again by key
+	keys := make([]interface{}, 0, len(old))
+	before := map[string]map[string]interface{}{}
+	for _, values := range old {
+		key := auditRowId(db, values)
+		keys = append(keys, key)
+		before[key] = values
+	}
+	rows := []map[string]interface{}{}
+	if err := auditQuery(db).Unscoped().Where(clause.IN{Column: clause.PrimaryColumn, Values: keys}).Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+
+	entries := []structs.AuditLog{}
+	for _, values := range rows {
+		changes := auditDiff(before[auditRowId(db, values)], values)
+		if len(changes) > 0 {
+			entries = append(entries, auditEntry(db, structs.AuditUpdate, values, changes))
+		}
+	}
+	writeAuditLogs(db, entries)
+}
+
+func (al AuditLogs) afterDelete(db *gorm.DB) {
+	old, ok := auditSnapshot(db)
+	if !ok || !al.audited(db) {
+		return
+	}
+
+	entries := []structs.AuditLog{}
+	for _, values := range old {
+		entries = append(entries, auditEntry(db, structs.AuditDelete, values, auditDiff(values, nil)))
+	}
+	writeAuditLogs(db, entries)
+}
+
+func auditQuery(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(db.Statement.Schema.ModelType).Interface())
+}
+
+func auditSnapshot(db *gorm.DB) ([]map[string]interface{}, bool) {
+	value, ok := db.InstanceGet(auditSnapshotKey)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := value.([]map[string]interface{})
+	return rows, ok
+}
+
+// auditKeys returns the primary keys set on the model of the statement
+func auditKeys(db *gorm.DB) []interface{} {
+	keys := []interface{}{}
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return keys
+	}
+	eachRow(db.Statement.ReflectValue, func(row reflect.Value) {
+		if value, zero := field.ValueOf(db.Statement.Context, row); !zero {
+			keys = append(keys, value)
+		}
+	})
+	return keys
+}
+
+func auditEntry(db *gorm.DB, action string, values map[string]interface{}, changes structs.AuditChanges) structs.AuditLog {
+	info := RequestInfoFrom(db.Statement.Context)
+	entry := structs.AuditLog{
+		// Not left to a BeforeCreate hook, the log inherits SkipHooks from changes like UpdateColumns
+		ID:        uuid.New(),
+		IP:        truncate(info.IP, structs.AuditIPLength),
+		RequestId: truncate(info.RequestId, structs.AuditRequestIdLength),
+		Table:     db.Statement.Table,
+		RowId:     auditRowId(db, values),
+		Action:    action,
+		Changes:   changes,
+	}
+	if actor, ok := ActorFrom(db.Statement.Context); ok {
+		entry.ActorId = &actor
+	}
+	return entry
+}
+
+// truncate cuts value to length characters, what a varchar column of that length holds
+func truncate(value string, length int) string {
+	for i := range value {
+		if length == 0 {
+			return value[:i]
+		}
+		length--
+	}
+	return value
+}
+
+// auditRowId formats the primary key of a row, read back into a map it is a pointer
+func auditRowId(db *gorm.DB, values map[string]interface{}) string {
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return ""
+	}
+	value := reflect.Indirect(reflect.ValueOf(values[field.DBName]))
+	if !value.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// auditDiff compares the JSON of every column, old or current is nil for a created or deleted row
+func auditDiff(old, current map[string]interface{}) structs.AuditChanges {
+	changes := structs.AuditChanges{}
+	updated := old != nil && current != nil
+	for _, values := range []map[string]interface{}{old, current} {
+		for column := range values {
+			if _, done := changes[column]; done || (updated && slices.Contains(auditIgnored, column)) {
+				continue
+			}
+
+			change := structs.AuditChange{Old: auditValue(old, column), New: auditValue(current, column)}
+			if string(change.Old) == string(change.New) {
+				continue
+			}
+			if slices.Contains(auditMasked, column) {
+				change = structs.AuditChange{Old: auditMask(change.Old), New: auditMask(change.New)}
+			}
+			changes[column] = change
+		}
+	}
+	return changes
+}
+
+func auditValue(values map[string]interface{}, column string) json.RawMessage {
+	value, ok := values[column]
+	if !ok {
+		return json.RawMessage("null")
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		// Values JSON can not represent are kept in their printed form
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return encoded
+}
+
+func auditMask(value json.RawMessage) json.RawMessage {
+	if string(value) == "null" {
+		return value
+	}
+	return json.RawMessage(`"********"`)
+}
+
+func writeAuditLogs(db *gorm.DB, entries []structs.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	db.AddError(db.Session(&gorm.Session{NewDB: true}).Create(&entries).Error)
+}
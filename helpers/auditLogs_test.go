@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"simple-crud-rnd/structs"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditEntryFitsRequestInfo(t *testing.T) {
+	ctx := WithRequestInfo(context.Background(), RequestInfo{
+		IP:        strings.Repeat("1", 300),
+		RequestId: strings.Repeat("é", 500),
+	})
+	db := dryRunDB(t).WithContext(ctx)
+	if err := db.Statement.Parse(&structs.Product{}); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := auditEntry(db, structs.AuditCreate, map[string]interface{}{"id": uuid.New()}, nil)
+	if got := len([]rune(entry.IP)); got != structs.AuditIPLength {
+		t.Errorf("IP is %d characters, the column holds %d", got, structs.AuditIPLength)
+	}
+	if entry.RequestId != strings.Repeat("é", structs.AuditRequestIdLength) {
+		t.Errorf("request ID was cut to %q", entry.RequestId)
+	}
+}
+
+func TestAuditEntryMasksUserPassword(t *testing.T) {
+	db := dryRunDB(t)
+	if err := db.Statement.Parse(&structs.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	id := uuid.New()
+	old := map[string]interface{}{
+		"id":         &id,
+		"name":       "Alice",
+		"email":      "alice@example.com",
+		"password":   "$2a$10$old-hash",
+		"updated_at": "2024-01-01 10:00:00",
+	}
+	current := map[string]interface{}{
+		"id":         &id,
+		"name":       "Alice Smith",
+		"email":      "alice@example.com",
+		"password":   "$2a$10$new-hash",
+		"updated_at": "2024-01-02 10:00:00",
+	}
+
+	entry := auditEntry(db, structs.AuditUpdate, current, auditDiff(old, current))
+	if entry.Table != "m_user" || entry.RowId != id.String() || entry.Action != structs.AuditUpdate {
+		t.Errorf("entry of %s %s %s, want m_user %s update", entry.Table, entry.RowId, entry.Action, id)
+	}
+
+	want := structs.AuditChanges{
+		"name":     {Old: json.RawMessage(`"Alice"`), New: json.RawMessage(`"Alice Smith"`)},
+		"password": {Old: json.RawMessage(`"********"`), New: json.RawMessage(`"********"`)},
+	}
+	if !reflect.DeepEqual(entry.Changes, want) {
+		t.Errorf("changes = %s, want %s", auditJSON(t, entry.Changes), auditJSON(t, want))
+	}
+	if changes := auditJSON(t, entry.Changes); strings.Contains(changes, "hash") {
+		t.Errorf("the password reaches the log: %s", changes)
+	}
+
+	// A created user records the fact a password was set, never its value
+	created := auditDiff(nil, current)["password"]
+	if string(created.Old) != "null" || string(created.New) != `"********"` {
+		t.Errorf("password of a created user is logged as %s -> %s", created.Old, created.New)
+	}
+}
+
+func auditJSON(t *testing.T, changes structs.AuditChanges) string {
+	t.Helper()
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(encoded)
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		value  string
+		length int
+		want   string
+	}{
+		{"", 3, ""},
+		{"abc", 3, "abc"},
+		{"abcd", 3, "abc"},
+		{"héllo", 2, "hé"},
+	}
+	for _, test := range tests {
+		if got := truncate(test.value, test.length); got != test.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", test.value, test.length, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"simple-crud-rnd/structs"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type actorKey struct{}
+
+// GetJWTUser returns the claims of the token verified by echojwt
+func GetJWTUser(c echo.Context) (*structs.JWTUser, error) {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return nil, errors.New("missing access token")
+	}
+	claims, ok := token.Claims.(*structs.JWTUser)
+	if !ok {
+		return nil, errors.New("invalid access token claims")
+	}
+	return claims, nil
+}
+
+// WithActor returns a copy of ctx carrying the ID of the authenticated user, queries run with it
+// through gorm.DB.WithContext are attributed to that user by the AuditColumns plugin
+func WithActor(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorKey{}, id)
+}
+
+// ActorFrom returns the user ID stored by WithActor
+func ActorFrom(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(actorKey{}).(uuid.UUID)
+	return id, ok
+}
@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CSVResponse streams the rows as a downloadable CSV file
+func CSVResponse(c echo.Context, filename string, header []string, rows [][]string) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+	if err := w.Write(escapeCSVRow(header)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := w.Write(escapeCSVRow(row)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// escapeCSVRow prefixes the cells a spreadsheet would run as a formula with a quote, so a customer
+// named =HYPERLINK(...) is shown as text when the report is opened
+func escapeCSVRow(row []string) []string {
+	escaped := make([]string, len(row))
+	for i, cell := range row {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			cell = "'" + cell
+		}
+		escaped[i] = cell
+	}
+	return escaped
+}
@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCSVResponseEscapesFormulas(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest("GET", "/", nil), rec)
+
+	header := []string{"name", "total"}
+	rows := [][]string{
+		{"=HYPERLINK(\"http://evil\",\"x\")", "10.00"},
+		{"+1+1", "-5.00"},
+		{"@SUM(A1)", "\t=1"},
+		{"Tea = good", ""},
+	}
+	if err := CSVResponse(c, "report.csv", header, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"name", "total"},
+		{"'=HYPERLINK(\"http://evil\",\"x\")", "10.00"},
+		{"'+1+1", "'-5.00"},
+		{"'@SUM(A1)", "'\t=1"},
+		{"Tea = good", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	if rows[0][0][0] != '=' {
+		t.Errorf("the caller's rows were escaped in place")
+	}
+}
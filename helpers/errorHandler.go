@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	"errors"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/structs"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPErrorHandler answers every error returned by a handler or middleware with the JSONResponse
+// envelope, see apperrors.From for how errors map to a status and code
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	appErr := apperrors.From(err)
+	if appErr.Status >= http.StatusInternalServerError {
+		HandleError(c.Request().Method+" "+c.Path(), err)
+	}
+
+	response := structs.JSONResponse{
+		ResponseCode:    appErr.Status,
+		ResponseMessage: T(c, getMessage(appErr.Status)),
+		Message:         T(c, appErr.Message, appErr.Params...),
+		ErrorCode:       appErr.Code,
+	}
+	if appErr.Code == apperrors.CodeValidation {
+		trans, _ := c.Get("locale").(ut.Translator)
+		response.Errors, _ = ValidationErrors(errors.Unwrap(appErr), trans)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(appErr.Status)
+	} else {
+		err = c.JSON(appErr.Status, response)
+	}
+	if err != nil {
+		HandleError("Failed to write the error response", err)
+	}
+}
+
+// ParamUUID parses a path parameter, answering 400 instead of a bare parse error
+func ParamUUID(c echo.Context, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		return id, apperrors.New(http.StatusBadRequest, apperrors.CodeInvalidId, "{0} must be a valid UUID").With(name)
+	}
+	return id, nil
+}
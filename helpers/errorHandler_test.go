@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"simple-crud-rnd/structs"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+		// logged is a detail that must reach the log and never the client
+		logged string
+	}{
+		{
+			name:    "sentinel",
+			err:     ErrInvalidImage,
+			status:  http.StatusBadRequest,
+			code:    "invalid_image",
+			message: "invalid image",
+		},
+		{
+			name:    "wrapped sentinel keeps its catalog message",
+			err:     fmt.Errorf("%w: content can not be decoded", ErrInvalidImage),
+			status:  http.StatusBadRequest,
+			code:    "invalid_image",
+			message: "invalid image",
+		},
+		{
+			name:    "wrapped sentinel keeps its params",
+			err:     fmt.Errorf("storing the upload: %w", ErrFileTooLarge.With("1024")),
+			status:  http.StatusRequestEntityTooLarge,
+			code:    "file_too_large",
+			message: "File is larger than 1024 bytes",
+		},
+		{
+			name:    "record not found",
+			err:     fmt.Errorf("loading the sale: %w", gorm.ErrRecordNotFound),
+			status:  http.StatusNotFound,
+			code:    "not_found",
+			message: "record not found",
+		},
+		{
+			name:    "unknown error",
+			err:     errors.New("dial tcp 10.0.0.5:3306: connection refused"),
+			status:  http.StatusInternalServerError,
+			code:    "internal_error",
+			message: "Internal Server Error",
+			logged:  "10.0.0.5:3306",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			output := log.Writer()
+			log.SetOutput(&logs)
+			defer log.SetOutput(output)
+
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest("GET", "/", nil), rec)
+			HTTPErrorHandler(tt.err, c)
+
+			response := structs.JSONResponse{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status || response.ResponseCode != tt.status {
+				t.Errorf("status %d and response_code %d, want %d", rec.Code, response.ResponseCode, tt.status)
+			}
+			if response.ErrorCode != tt.code {
+				t.Errorf("error_code = %q, want %q", response.ErrorCode, tt.code)
+			}
+			if response.Message != tt.message {
+				t.Errorf("message = %q, want %q", response.Message, tt.message)
+			}
+			if tt.logged != "" {
+				if strings.Contains(rec.Body.String(), tt.logged) {
+					t.Errorf("%q is sent to the client: %s", tt.logged, rec.Body.String())
+				}
+				if !strings.Contains(logs.String(), tt.logged) {
+					t.Errorf("%q is not logged", tt.logged)
+				}
+			}
+		})
+	}
+}
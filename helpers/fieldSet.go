@@ -0,0 +1,136 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/structs"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidField   = apperrors.New(http.StatusBadRequest, "invalid_field", "{0} is not a field that can be selected")
+	ErrInvalidInclude = apperrors.New(http.StatusBadRequest, "invalid_include", "{0} can not be included")
+)
+
+// ParseFieldSet reads fields=id,name and include=role from the query string, rejecting every
+// field and relation the schema does not allow
+func ParseFieldSet(c echo.Context, schema structs.QuerySchema) (structs.FieldSet, error) {
+	fields := structs.FieldSet{}
+
+	for _, name := range splitParam(c.QueryParam("fields")) {
+		column, ok := schema.Selectable[name]
+		if !ok {
+			return fields, ErrInvalidField.With(name)
+		}
+		fields.Fields = append(fields.Fields, name)
+		fields.Columns = appendUnique(fields.Columns, column)
+	}
+
+	for _, name := range splitParam(c.QueryParam("include")) {
+		include, ok := schema.Includes[name]
+		if !ok {
+			return fields, ErrInvalidInclude.With(name)
+		}
+		if fields.Fields != nil {
+			fields.Fields = appendUnique(fields.Fields, name)
+		}
+		if include.Column != "" && fields.Columns != nil {
+			fields.Columns = appendUnique(fields.Columns, include.Column)
+		}
+		fields.Preloads = appendUnique(fields.Preloads, include.Preload)
+		for _, nested := range include.Nested {
+			fields.Preloads = appendUnique(fields.Preloads, include.Preload+"."+nested)
+		}
+	}
+
+	// Relations and the links of the response are resolved through the id
+	if fields.Columns != nil {
+		fields.Columns = appendUnique(fields.Columns, "id")
+	}
+	return fields, nil
+}
+
+// SelectFields selects the columns of the field set, or every selectable column of the schema
+// when no fields were asked for
+func SelectFields(db *gorm.DB, schema structs.QuerySchema, fields structs.FieldSet) *gorm.DB {
+	columns := fields.Columns
+	if columns == nil {
+		for _, column := range schema.Selectable {
+			columns = appendUnique(columns, column)
+		}
+		slices.Sort(columns)
+	}
+	return db.Select(columns)
+}
+
+// SparseFields drops the JSON fields of data, a model or a slice of models, that were not asked for
+func SparseFields(data interface{}, fields structs.FieldSet) interface{} {
+	if fields.Fields == nil {
+		return data
+	}
+	return sparseData{data, fields.Fields}
+}
+
+type sparseData struct {
+	data   interface{}
+	fields []string
+}
+
+func (sd sparseData) MarshalJSON() ([]byte, error) {
+	encoded, err := json.Marshal(sd.data)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+
+	rows, ok := decoded.([]interface{})
+	if !ok {
+		rows = []interface{}{decoded}
+	}
+	for _, row := range rows {
+		object, ok := row.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range object {
+			if !slices.Contains(sd.fields, key) {
+				delete(object, key)
+			}
+		}
+	}
+	return json.Marshal(decoded)
+}
+
+// Preload eager-loads every relation
+func Preload(db *gorm.DB, preloads ...string) *gorm.DB {
+	for _, preload := range preloads {
+		db = db.Preload(preload)
+	}
+	return db
+}
+
+func splitParam(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func appendUnique(items []string, item string) []string {
+	if slices.Contains(items, item) {
+		return items
+	}
+	return append(items, item)
+}
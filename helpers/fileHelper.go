@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrFileTooLarge    = apperrors.New(http.StatusRequestEntityTooLarge, "file_too_large", "File is larger than {0} bytes")
+	ErrUnsupportedFile = apperrors.New(http.StatusUnsupportedMediaType, "unsupported_file_type", "Files of this type are not accepted")
+)
+
+// fileType is an extension files are stored with, the type they are served as and the types
+// http.DetectContentType sniffs from files that really are of that kind
+type fileType struct {
+	contentType string
+	sniffed     []string
+}
+
+var (
+	sniffedText = []string{"text/plain; charset=utf-8"}
+	sniffedZip  = []string{"application/zip"}
+	// Office documents before 2007 are not recognized by the sniffer
+	sniffedOfficeDocument = []string{"application/octet-stream"}
+)
+
+// fileTypes lists what files may be stored as. Public files are served from the origin of the API,
+// so nothing a browser renders as a page, such as .html or .svg, is accepted.
+var fileTypes = map[string]fileType{
+	".pdf":  {"application/pdf", []string{"application/pdf"}},
+	".txt":  {"text/plain; charset=utf-8", sniffedText},
+	".csv":  {"text/csv; charset=utf-8", sniffedText},
+	".json": {"application/json", sniffedText},
+	".zip":  {"application/zip", sniffedZip},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", sniffedZip},
+	".xlsx": {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", sniffedZip},
+	".pptx": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", sniffedZip},
+	".doc":  {"application/msword", sniffedOfficeDocument},
+	".xls":  {"application/vnd.ms-excel", sniffedOfficeDocument},
+	".png":  {"image/png", []string{"image/png"}},
+	".jpg":  {"image/jpeg", []string{"image/jpeg"}},
+	".jpeg": {"image/jpeg", []string{"image/jpeg"}},
+	".gif":  {"image/gif", []string{"image/gif"}},
+	".webp": {"image/webp", []string{"image/webp"}},
+}
+
+// FileHelper stores non image assets such as documents and catalog imports as they are
+type FileHelper struct {
+	storage  Storage
+	category string
+	maxSize  int64
+}
+
+func NewFileHelper(storage Storage, category string, maxSize int64) *FileHelper {
+	return &FileHelper{storage, category, maxSize}
+}
+
+// Stage hashes src and names it by the SHA-256 of its content plus ext, so identical uploads share
+// one file. ext must be one of fileTypes and agree with the sniffed content, otherwise
+// ErrUnsupportedFile is returned. src is read again from the start by Write, it must stay open until then.
+func (fh *FileHelper) Stage(src io.ReadSeeker, ext string) (*StagedContent, error) {
+	ext = strings.ToLower(ext)
+	fileType, ok := fileTypes[ext]
+	if !ok {
+		return nil, ErrUnsupportedFile
+	}
+
+	// Peek at the head of the file to sniff its content type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if !slices.Contains(fileType.sniffed, http.DetectContentType(head[:n])) {
+		return nil, ErrUnsupportedFile
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// Read at most one byte over the limit to detect oversized files
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, io.LimitReader(src, fh.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > fh.maxSize {
+		return nil, ErrFileTooLarge.With(strconv.FormatInt(fh.maxSize, 10))
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	path := fmt.Sprintf("%s/%s/%s%s", "files", fh.category, hash, ext)
+	contentType := fileType.contentType
+
+	return &StagedContent{
+		Hash:        hash,
+		Path:        path,
+		ContentType: contentType,
+		Size:        size,
+		write: func() error {
+			if _, err := src.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			return fh.storage.Put(path, io.LimitReader(src, size), size, contentType)
+		},
+	}, nil
+}
+
+// Accepts reports whether files with the extension may be stored, whatever their content
+func (fh *FileHelper) Accepts(ext string) bool {
+	_, ok := fileTypes[strings.ToLower(ext)]
+	return ok
+}
+
+func (fh *FileHelper) URL(path string) string {
+	return fh.storage.URL(path)
+}
+
+func (fh *FileHelper) Category() string {
+	return fh.category
+}
+
+func (fh *FileHelper) MaxSize() int64 {
+	return fh.maxSize
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFileHelperStageChecksType(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileHelper := NewFileHelper(storage, "documents", 1<<20)
+
+	tests := []struct {
+		name        string
+		ext         string
+		content     string
+		contentType string
+	}{
+		{"pdf", ".pdf", "%PDF-1.7\n", "application/pdf"},
+		{"upper case extension", ".PDF", "%PDF-1.7\n", "application/pdf"},
+		{"csv", ".csv", "name,price\ntea,1\n", "text/csv; charset=utf-8"},
+		{"html", ".html", "<html><script>alert(1)</script></html>", ""},
+		{"svg", ".svg", `<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`, ""},
+		{"html named csv", ".csv", "<html><script>alert(1)</script></html>", ""},
+		{"html named pdf", ".pdf", "<html><script>alert(1)</script></html>", ""},
+		{"no extension", "", "%PDF-1.7\n", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			staged, err := fileHelper.Stage(strings.NewReader(test.content), test.ext)
+			if test.contentType == "" {
+				if !errors.Is(err, ErrUnsupportedFile) {
+					t.Fatalf("got %v, want ErrUnsupportedFile", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if staged.ContentType != test.contentType {
+				t.Errorf("content type %q, want %q", staged.ContentType, test.contentType)
+			}
+			if !strings.HasSuffix(staged.Path, strings.ToLower(test.ext)) {
+				t.Errorf("stored as %s", staged.Path)
+			}
+		})
+	}
+}
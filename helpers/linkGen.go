@@ -5,17 +5,18 @@ import (
 	"net/url"
 )
 
-func LinksGenerator(base string, pageCount int, params map[string]interface{}) []string {
+// LinksGenerator builds a link to base for every value of key, keeping the other query params
+func LinksGenerator(base string, params url.Values, key string, values ...string) []string {
 	links := []string{}
 
-	for page := range pageCount {
-		link := fmt.Sprintf("%s?", base)
-		for param, value := range params {
-			link += fmt.Sprintf("%s=%s&", url.QueryEscape(param), url.QueryEscape(fmt.Sprint(value)))
+	for _, value := range values {
+		query := url.Values{}
+		for param, paramValues := range params {
+			query[param] = paramValues
 		}
-		link += fmt.Sprintf("page=%d", page)
+		query.Set(key, value)
 
-		links = append(links, link)
+		links = append(links, fmt.Sprintf("%s?%s", base, query.Encode()))
 	}
 	return links
 }
@@ -0,0 +1,193 @@
+package helpers
+
+import (
+	"net/http"
+	"regexp"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/structs"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidSort   = apperrors.New(http.StatusBadRequest, "invalid_sort", "Sorting by {0} is not supported")
+	ErrInvalidFilter = apperrors.New(http.StatusBadRequest, "invalid_filter", "Filtering {0} with {1} is not supported")
+	ErrFilterValue   = apperrors.New(http.StatusBadRequest, "invalid_filter_value", "filter[{0}] must be a {1}")
+)
+
+// filterParam matches filter[field] and filter[field][operator]
+var filterParam = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// likeEscaper makes % and _ in a like filter match themselves
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ParseListQuery reads sort=-created_at,name and filter[email][like]=... from the query string,
+// rejecting every field and operator the schema does not allow
+func ParseListQuery(c echo.Context, schema structs.QuerySchema) (structs.ListQuery, error) {
+	query := structs.ListQuery{}
+
+	sorts := c.QueryParam("sort")
+	if sorts == "" {
+		sorts = schema.DefaultSort
+	}
+	for _, name := range strings.Split(sorts, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		field, ok := schema.Fields[name]
+		if !ok || !field.Sortable {
+			return query, ErrInvalidSort.With(name)
+		}
+		query.Sorts = append(query.Sorts, structs.QuerySort{Column: field.Column, Desc: desc, Type: field.Type, Nullable: field.Nullable})
+	}
+
+	// Sort the keys so the SQL, and therefore the query plan cache, is stable
+	params := c.QueryParams()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		match := filterParam.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		name, operator := match[1], match[2]
+		if operator == "" {
+			operator = "eq"
+		}
+
+		field, ok := schema.Fields[name]
+		allowed := slices.Contains(field.Operators, operator) || (operator == "null" && field.Nullable)
+		if !ok || !allowed {
+			return query, ErrInvalidFilter.With(name, operator)
+		}
+
+		for _, raw := range params[key] {
+			value, err := parseFilterValue(field, operator, raw)
+			if err != nil {
+				return query, ErrFilterValue.With(name, filterTypeName(field, operator))
+			}
+			query.Filters = append(query.Filters, structs.QueryFilter{Column: field.Column, Operator: operator, Value: value})
+		}
+	}
+
+	return query, nil
+}
+
+// ApplyFilters adds the WHERE conditions of the query, use it for the count as well as the page
+func ApplyFilters(db *gorm.DB, query structs.ListQuery) *gorm.DB {
+	for _, filter := range query.Filters {
+		column := clause.Column{Name: filter.Column}
+		var expression clause.Expression
+		switch filter.Operator {
+		case "eq":
+			expression = clause.Eq{Column: column, Value: filter.Value}
+		case "ne":
+			expression = clause.Neq{Column: column, Value: filter.Value}
+		case "gt":
+			expression = clause.Gt{Column: column, Value: filter.Value}
+		case "gte":
+			expression = clause.Gte{Column: column, Value: filter.Value}
+		case "lt":
+			expression = clause.Lt{Column: column, Value: filter.Value}
+		case "lte":
+			expression = clause.Lte{Column: column, Value: filter.Value}
+		case "like":
+			expression = clause.Like{Column: column, Value: "%" + likeEscaper.Replace(filter.Value.(string)) + "%"}
+		case "in":
+			expression = clause.IN{Column: column, Values: filter.Value.([]interface{})}
+		case "null":
+			// clause.Eq with a nil value renders IS NULL
+			expression = clause.Eq{Column: column, Value: nil}
+			if !filter.Value.(bool) {
+				expression = clause.Neq{Column: column, Value: nil}
+			}
+		default:
+			continue
+		}
+		db = db.Clauses(clause.Where{Exprs: []clause.Expression{expression}})
+	}
+	return db
+}
+
+// ApplySorts adds the ORDER BY of the query
+func ApplySorts(db *gorm.DB, query structs.ListQuery) *gorm.DB {
+	for _, order := range query.Sorts {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: order.Column}, Desc: order.Desc})
+	}
+	return db
+}
+
+func parseFilterValue(field structs.QueryField, operator, raw string) (interface{}, error) {
+	switch operator {
+	case "null":
+		return strconv.ParseBool(raw)
+	case "like":
+		return raw, nil
+	case "in":
+		values := []interface{}{}
+		for _, item := range strings.Split(raw, ",") {
+			value, err := parseFieldValue(field.Type, strings.TrimSpace(item))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	default:
+		return parseFieldValue(field.Type, raw)
+	}
+}
+
+func parseFieldValue(fieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case structs.QueryNumber:
+		return strconv.ParseFloat(raw, 64)
+	case structs.QueryBool:
+		return strconv.ParseBool(raw)
+	case structs.QueryUUID:
+		return uuid.Parse(raw)
+	case structs.QueryTime:
+		// A plain date means midnight in the server time zone, like the report filters
+		if date, err := time.ParseInLocation(time.DateOnly, raw, time.Local); err == nil {
+			return date, nil
+		}
+		return time.Parse(time.RFC3339, raw)
+	default:
+		return raw, nil
+	}
+}
+
+func filterTypeName(field structs.QueryField, operator string) string {
+	switch {
+	case operator == "null":
+		return "boolean"
+	case operator == "in":
+		return "comma separated list of " + filterTypeName(field, "eq") + " values"
+	case field.Type == structs.QueryNumber:
+		return "number"
+	case field.Type == structs.QueryBool:
+		return "boolean"
+	case field.Type == structs.QueryUUID:
+		return "UUID"
+	case field.Type == structs.QueryTime:
+		return "date (YYYY-MM-DD) or RFC 3339 time"
+	default:
+		return "text"
+	}
+}
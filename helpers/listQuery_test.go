@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"simple-crud-rnd/structs"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var testQuerySchema = structs.QuerySchema{
+	DefaultSort: "-created_at",
+	Fields: map[string]structs.QueryField{
+		"name":        {Column: "name", Type: structs.QueryText, Sortable: true, Operators: structs.TextOperators},
+		"price":       {Column: "price", Type: structs.QueryNumber, Sortable: true, Operators: structs.RangeOperators},
+		"is_active":   {Column: "is_active", Type: structs.QueryBool, Operators: structs.EqualOperators},
+		"category_id": {Column: "product_category_id", Type: structs.QueryUUID, Nullable: true, Operators: structs.EqualOperators},
+		"created_at":  {Column: "created_at", Type: structs.QueryTime, Sortable: true, Operators: structs.RangeOperators},
+	},
+}
+
+func queryContext(rawQuery string) echo.Context {
+	return echo.New().NewContext(httptest.NewRequest("GET", "/?"+rawQuery, nil), httptest.NewRecorder())
+}
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, SkipDefaultTransaction: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestParseListQuerySorts(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []structs.QuerySort
+		err   error
+	}{
+		{"default", "", []structs.QuerySort{{Column: "created_at", Desc: true, Type: structs.QueryTime}}, nil},
+		{"several", "sort=-price,name", []structs.QuerySort{{Column: "price", Desc: true, Type: structs.QueryNumber}, {Column: "name", Type: structs.QueryText}}, nil},
+		{"blanks are skipped", "sort=name,,", []structs.QuerySort{{Column: "name", Type: structs.QueryText}}, nil},
+		{"unknown field", "sort=password", nil, ErrInvalidSort},
+		{"field that is not sortable", "sort=is_active", nil, ErrInvalidSort},
+		{"column name instead of field", "sort=product_category_id", nil, ErrInvalidSort},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := ParseListQuery(queryContext(test.query), testQuerySchema)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if test.err == nil && !reflect.DeepEqual(query.Sorts, test.want) {
+				t.Errorf("got %+v, want %+v", query.Sorts, test.want)
+			}
+		})
+	}
+}
+
+func TestParseListQueryFilters(t *testing.T) {
+	categoryId := uuid.MustParse("4f8d3c6e-0b1a-4c8e-9d2f-6a7b8c9d0e1f")
+	tests := []struct {
+		name  string
+		query string
+		want  []structs.QueryFilter
+		err   error
+	}{
+		{"eq without operator", "filter[name]=tea", []structs.QueryFilter{{Column: "name", Operator: "eq", Value: "tea"}}, nil},
+		{"number", "filter[price][gte]=10.5", []structs.QueryFilter{{Column: "price", Operator: "gte", Value: 10.5}}, nil},
+		{"boolean", "filter[is_active]=false", []structs.QueryFilter{{Column: "is_active", Operator: "eq", Value: false}}, nil},
+		{"uuid maps to its column", "filter[category_id]=" + categoryId.String(), []structs.QueryFilter{{Column: "product_category_id", Operator: "eq", Value: categoryId}}, nil},
+		{"like keeps the raw value", "filter[name][like]=50%25_off", []structs.QueryFilter{{Column: "name", Operator: "like", Value: "50%_off"}}, nil},
+		{"in list", "filter[price][in]=1,%202,3", []structs.QueryFilter{{Column: "price", Operator: "in", Value: []interface{}{1.0, 2.0, 3.0}}}, nil},
+		{"null on a nullable field", "filter[category_id][null]=true", []structs.QueryFilter{{Column: "product_category_id", Operator: "null", Value: true}}, nil},
+		{"not null", "filter[category_id][null]=false", []structs.QueryFilter{{Column: "product_category_id", Operator: "null", Value: false}}, nil},
+		{"repeated parameter", "filter[price][gt]=1&filter[price][gt]=2", []structs.QueryFilter{{Column: "price", Operator: "gt", Value: 1.0}, {Column: "price", Operator: "gt", Value: 2.0}}, nil},
+		{"other parameters are ignored", "page=2&filters=x&filter[name]=tea", []structs.QueryFilter{{Column: "name", Operator: "eq", Value: "tea"}}, nil},
+		{"unknown field", "filter[password]=x", nil, ErrInvalidFilter},
+		{"column name instead of field", "filter[product_category_id]=x", nil, ErrInvalidFilter},
+		{"unknown operator", "filter[name][regex]=t.*", nil, ErrInvalidFilter},
+		{"operator not allowed for the field", "filter[name][gt]=a", nil, ErrInvalidFilter},
+		{"null on a field that is not nullable", "filter[name][null]=true", nil, ErrInvalidFilter},
+		{"invalid number", "filter[price]=cheap", nil, ErrFilterValue},
+		{"invalid uuid", "filter[category_id]=1", nil, ErrFilterValue},
+		{"invalid item of an in list", "filter[price][in]=1,two", nil, ErrFilterValue},
+		{"invalid null", "filter[category_id][null]=maybe", nil, ErrFilterValue},
+		{"invalid time", "filter[created_at][gte]=yesterday", nil, ErrFilterValue},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := ParseListQuery(queryContext(test.query), testQuerySchema)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if test.err == nil && !reflect.DeepEqual(query.Filters, test.want) {
+				t.Errorf("got %+v, want %+v", query.Filters, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter structs.QueryFilter
+		sql    string
+		vars   []interface{}
+	}{
+		{"eq", structs.QueryFilter{Column: "name", Operator: "eq", Value: "tea"}, "SELECT * FROM `products` WHERE `name` = ?", []interface{}{"tea"}},
+		{"lte", structs.QueryFilter{Column: "price", Operator: "lte", Value: 2.5}, "SELECT * FROM `products` WHERE `price` <= ?", []interface{}{2.5}},
+		{"like escapes wildcards", structs.QueryFilter{Column: "name", Operator: "like", Value: `50%_off\`}, "SELECT * FROM `products` WHERE `name` LIKE ?", []interface{}{`%50\%\_off\\%`}},
+		{"in", structs.QueryFilter{Column: "price", Operator: "in", Value: []interface{}{1.0, 2.0}}, "SELECT * FROM `products` WHERE `price` IN (?,?)", []interface{}{1.0, 2.0}},
+		{"null", structs.QueryFilter{Column: "product_category_id", Operator: "null", Value: true}, "SELECT * FROM `products` WHERE `product_category_id` IS NULL", nil},
+		{"not null", structs.QueryFilter{Column: "product_category_id", Operator: "null", Value: false}, "SELECT * FROM `products` WHERE `product_category_id` IS NOT NULL", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows := []map[string]interface{}{}
+			stmt := ApplyFilters(dryRunDB(t).Table("products"), structs.ListQuery{Filters: []structs.QueryFilter{test.filter}}).Find(&rows).Statement
+			if got := stmt.SQL.String(); got != test.sql {
+				t.Errorf("got %s, want %s", got, test.sql)
+			}
+			if len(stmt.Vars) != len(test.vars) || (len(test.vars) > 0 && !reflect.DeepEqual(stmt.Vars, test.vars)) {
+				t.Errorf("got vars %v, want %v", stmt.Vars, test.vars)
+			}
+		})
+	}
+}
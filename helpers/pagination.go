@@ -0,0 +1,162 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/structs"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCursor = apperrors.New(http.StatusBadRequest, "invalid_cursor", "Cursor is invalid or was issued for another sort")
+	ErrCursorSort    = apperrors.New(http.StatusBadRequest, "invalid_sort", "Sorting by {0} can not be combined with a cursor")
+)
+
+// cursor is what an opaque cursor decodes to, the sort it was issued for and the sort values
+// of the last row of the previous page
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// Paginate loads one page of query into dest, a pointer to a slice of models. Preloads are only
+// applied to the page, not to the count.
+//
+// In offset mode it counts the rows and skips the earlier pages. In cursor mode it never counts
+// and seeks past the last row of the previous page instead, which stays fast on large tables.
+// The primary key is always added as the last sort so rows with equal values keep their order.
+func Paginate(query *gorm.DB, page *structs.Pagination, list structs.ListQuery, dest interface{}, preloads ...string) error {
+	query = query.Session(&gorm.Session{})
+	list.Sorts = append(list.Sorts[:len(list.Sorts):len(list.Sorts)], structs.QuerySort{Column: "id", Type: structs.QueryUUID})
+
+	if page.Cursor == nil {
+		if err := query.Count(&page.Total).Error; err != nil {
+			return err
+		}
+		query = query.Limit(page.PerPage).Offset(page.Offset())
+	} else {
+		signature, err := sortSignature(list.Sorts)
+		if err != nil {
+			return err
+		}
+		if *page.Cursor != "" {
+			after, err := decodeCursor(*page.Cursor, signature, list.Sorts)
+			if err != nil {
+				return err
+			}
+			query = query.Where(after)
+		}
+		// One extra row tells whether there is a next page
+		query = query.Limit(page.PerPage + 1)
+
+		// The next cursor is read from the sort columns, even when the client did not ask for them
+		if selects := query.Statement.Selects; len(selects) > 0 {
+			selects = slices.Clone(selects)
+			for _, sort := range list.Sorts {
+				selects = appendUnique(selects, sort.Column)
+			}
+			query = query.Select(selects)
+		}
+	}
+
+	tx := ApplySorts(Preload(query, preloads...), list).Find(dest)
+	if tx.Error != nil || page.Cursor == nil {
+		return tx.Error
+	}
+
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() <= page.PerPage {
+		return nil
+	}
+	rows.Set(rows.Slice(0, page.PerPage))
+
+	next, err := encodeCursor(tx, rows.Index(page.PerPage-1), list.Sorts)
+	if err != nil {
+		return err
+	}
+	page.NextCursor = next
+	return nil
+}
+
+// sortSignature identifies a sort so a cursor can not be replayed against another one
+func sortSignature(sorts []structs.QuerySort) (string, error) {
+	columns := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		// NULL compares as neither greater nor smaller, it can not be seeked past
+		if sort.Nullable {
+			return "", ErrCursorSort.With(sort.Column)
+		}
+		if sort.Desc {
+			columns = append(columns, "-"+sort.Column)
+		} else {
+			columns = append(columns, sort.Column)
+		}
+	}
+	return strings.Join(columns, ","), nil
+}
+
+func encodeCursor(tx *gorm.DB, row reflect.Value, sorts []structs.QuerySort) (string, error) {
+	signature, err := sortSignature(sorts)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		field := tx.Statement.Schema.LookUpField(sort.Column)
+		if field == nil {
+			return "", fmt.Errorf("%s has no %s column", tx.Statement.Schema.Name, sort.Column)
+		}
+		value, _ := field.ValueOf(tx.Statement.Context, row)
+		if date, ok := value.(time.Time); ok {
+			value = date.Format(time.RFC3339Nano)
+		}
+		values = append(values, fmt.Sprint(value))
+	}
+
+	data, err := json.Marshal(cursor{Sort: signature, Values: values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor returns the condition selecting the rows after the cursor. For a sort of a, -b it
+// is a > ? OR (a = ? AND b < ?), the primary key being the last sort makes it exact.
+func decodeCursor(raw, signature string, sorts []structs.QuerySort) (clause.Expression, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	decoded := cursor{}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Sort != signature || len(decoded.Values) != len(sorts) {
+		return nil, ErrInvalidCursor
+	}
+
+	after := []clause.Expression{}
+	equal := []clause.Expression{}
+	for i, sort := range sorts {
+		value, err := parseFieldValue(sort.Type, decoded.Values[i])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+
+		column := clause.Column{Name: sort.Column}
+		var beyond clause.Expression = clause.Gt{Column: column, Value: value}
+		if sort.Desc {
+			beyond = clause.Lt{Column: column, Value: value}
+		}
+		after = append(after, clause.And(append(equal[:len(equal):len(equal)], beyond)...))
+		equal = append(equal, clause.Eq{Column: column, Value: value})
+	}
+	return clause.Or(after...), nil
+}
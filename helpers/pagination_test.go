@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"simple-crud-rnd/structs"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// testCursorSorts sorts by price, then newest first, then the primary key Paginate always adds
+var testCursorSorts = []structs.QuerySort{
+	{Column: "price", Type: structs.QueryNumber},
+	{Column: "created_at", Desc: true, Type: structs.QueryTime},
+	{Column: "id", Type: structs.QueryUUID},
+}
+
+// productCursor encodes the cursor pointing after the product, sorted by sorts
+func productCursor(t *testing.T, db *gorm.DB, product structs.Product, sorts []structs.QuerySort) string {
+	t.Helper()
+	tx := db.Find(&[]structs.Product{})
+	raw, err := encodeCursor(tx, reflect.ValueOf(product), sorts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestParsePaginationCapsPerPage(t *testing.T) {
+	tests := map[string]int{"": 10, "per_page=0": 10, "per_page=abc": 10, "per_page=25": 25, "per_page=100": 100, "per_page=100000": MaxPerPage}
+	for query, want := range tests {
+		if got := ParsePagination(queryContext(query)).PerPage; got != want {
+			t.Errorf("%q gives per_page %d, want %d", query, got, want)
+		}
+	}
+}
+
+func TestCursorKeysetPredicate(t *testing.T) {
+	db := dryRunDB(t)
+	product := structs.Product{
+		ID:        uuid.MustParse("4f8d3c6e-0b1a-4c8e-9d2f-6a7b8c9d0e1f"),
+		Price:     12.5,
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	raw := productCursor(t, db, product, testCursorSorts)
+
+	signature, err := sortSignature(testCursorSorts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := decodeCursor(raw, signature, testCursorSorts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt := db.Table("m_product").Where(after).Find(&[]map[string]interface{}{}).Statement
+	want := "SELECT * FROM `m_product` WHERE (`price` > ? OR (`price` = ? AND `created_at` < ?) OR (`price` = ? AND `created_at` = ? AND `id` > ?))"
+	if got := stmt.SQL.String(); got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+	wantVars := []interface{}{12.5, 12.5, product.CreatedAt, 12.5, product.CreatedAt, product.ID}
+	if len(stmt.Vars) != len(wantVars) {
+		t.Fatalf("got vars %v, want %v", stmt.Vars, wantVars)
+	}
+	for i, v := range stmt.Vars {
+		if date, ok := v.(time.Time); ok {
+			if !date.Equal(wantVars[i].(time.Time)) {
+				t.Errorf("var %d is %v, want %v", i, date, wantVars[i])
+			}
+		} else if v != wantVars[i] {
+			t.Errorf("var %d is %v, want %v", i, v, wantVars[i])
+		}
+	}
+}
+
+func TestCursorKeepsTimePrecision(t *testing.T) {
+	db := dryRunDB(t)
+	zone := time.FixedZone("WIB", 7*60*60)
+	product := structs.Product{ID: uuid.New(), CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456789, zone)}
+	sorts := []structs.QuerySort{{Column: "created_at", Desc: true, Type: structs.QueryTime}, {Column: "id", Type: structs.QueryUUID}}
+	raw := productCursor(t, db, product, sorts)
+
+	signature, _ := sortSignature(sorts)
+	after, err := decodeCursor(raw, signature, sorts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cursor rounded to the second would skip or repeat rows created within that second
+	stmt := db.Table("m_product").Where(after).Find(&[]map[string]interface{}{}).Statement
+	if got := stmt.Vars[0].(time.Time); !got.Equal(product.CreatedAt) {
+		t.Errorf("cursor decoded to %s, want %s", got.Format(time.RFC3339Nano), product.CreatedAt.Format(time.RFC3339Nano))
+	}
+}
+
+func TestCursorRejectsOtherSorts(t *testing.T) {
+	db := dryRunDB(t)
+	raw := productCursor(t, db, structs.Product{ID: uuid.New(), Price: 1}, testCursorSorts)
+
+	reversed := []structs.QuerySort{
+		{Column: "price", Desc: true, Type: structs.QueryNumber},
+		{Column: "created_at", Desc: true, Type: structs.QueryTime},
+		{Column: "id", Type: structs.QueryUUID},
+	}
+	tests := map[string]struct {
+		raw   string
+		sorts []structs.QuerySort
+	}{
+		"other direction":  {raw, reversed},
+		"fewer columns":    {raw, testCursorSorts[1:]},
+		"not base64":       {"not a cursor!", testCursorSorts},
+		"not json":         {base64.RawURLEncoding.EncodeToString([]byte("price")), testCursorSorts},
+		"value count":      {base64.RawURLEncoding.EncodeToString([]byte(`{"s":"price,-created_at,id","v":["1"]}`)), testCursorSorts},
+		"value of a field": {base64.RawURLEncoding.EncodeToString([]byte(`{"s":"price,-created_at,id","v":["cheap","2026-01-02T03:04:05Z","` + uuid.NewString() + `"]}`)), testCursorSorts},
+	}
+	for name, test := range tests {
+		signature, err := sortSignature(test.sorts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := decodeCursor(test.raw, signature, test.sorts); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: got %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+func TestCursorRejectsNullableSorts(t *testing.T) {
+	sorts := []structs.QuerySort{{Column: "deleted_at", Type: structs.QueryTime, Nullable: true}, {Column: "id", Type: structs.QueryUUID}}
+	if _, err := sortSignature(sorts); !errors.Is(err, ErrCursorSort) {
+		t.Errorf("got %v, want ErrCursorSort", err)
+	}
+}
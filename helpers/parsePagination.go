@@ -2,37 +2,45 @@ package helpers
 
 import (
 	"log"
+	"simple-crud-rnd/structs"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
 
-// ParsePagination menguraikan parameter halaman dan per halaman dari query
-func ParsePagination(c echo.Context) (perPage int, page int, offset int, sort string) {
+// MaxPerPage membatasi per_page agar satu permintaan tidak memuat seluruh tabel
+const MaxPerPage = 100
+
+// ParsePagination menguraikan parameter halaman dan per halaman dari query. Parameter cursor,
+// walaupun kosong, memilih mode cursor.
+func ParsePagination(c echo.Context) structs.Pagination {
 	var err error
+	page := structs.Pagination{}
 
 	// Menguraikan per_page
-	perPage, err = strconv.Atoi(c.QueryParam("per_page"))
-	if err != nil || perPage <= 0 {
-		perPage = 10
+	page.PerPage, err = strconv.Atoi(c.QueryParam("per_page"))
+	if err != nil || page.PerPage <= 0 {
+		page.PerPage = 10
 		log.Println("Failed to parse per_page query parameter or per_page <= 0. Defaulting to 10")
 	}
-
-	// Menguraikan page
-	page, err = strconv.Atoi(c.QueryParam("page"))
-	if err != nil || page <= 0 {
-		page = 1
-		log.Println("Failed to parse page query parameter or page <= 0. Defaulting to 1")
+	if page.PerPage > MaxPerPage {
+		page.PerPage = MaxPerPage
 	}
 
-	// Menghitung offset berdasarkan halaman dan jumlah item per halaman
-	offset = (page - 1) * perPage
+	// Menguraikan cursor, halaman tidak dipakai pada mode cursor
+	if c.QueryParams().Has("cursor") {
+		cursor := c.QueryParam("cursor")
+		page.Cursor = &cursor
+		return page
+	}
 
-	// Menguraikan sort
-	sort = c.QueryParam("sort")
-	if sort == "" {
-		sort = "created_at DESC"
+	// Menguraikan page
+	page.Page, err = strconv.Atoi(c.QueryParam("page"))
+	if err != nil || page.Page <= 0 {
+		page.Page = 1
+		log.Println("Failed to parse page query parameter or page <= 0. Defaulting to 1")
 	}
 
-	return perPage, page, offset, sort
+	// Urutan dan filter diuraikan oleh ParseListQuery, yang hanya menerima kolom yang diizinkan
+	return page
 }
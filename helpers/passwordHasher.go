@@ -8,3 +8,7 @@ func PasswordHash(passwd string) (string, error) {
 		GenerateFromPassword(passwordBytes, bcrypt.MinCost)
 	return string(hashedPasswordBytes), err
 }
+
+func PasswordVerify(hashedPasswd, passwd string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPasswd), []byte(passwd)) == nil
+}
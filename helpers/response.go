@@ -1,8 +1,11 @@
 package helpers
 
 import (
+	"fmt"
 	"net/http"
+	"simple-crud-rnd/apperrors"
 	"simple-crud-rnd/structs"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
@@ -21,6 +24,18 @@ func getMessage(status int) string {
 		return "Forbidden"
 	case http.StatusNotFound:
 		return "Not Found"
+	case http.StatusMethodNotAllowed:
+		return "Method Not Allowed"
+	case http.StatusConflict:
+		return "Conflict"
+	case http.StatusGone:
+		return "Gone"
+	case http.StatusRequestEntityTooLarge:
+		return "Request Entity Too Large"
+	case http.StatusUnprocessableEntity:
+		return "Unprocessable Entity"
+	case http.StatusTooManyRequests:
+		return "Too Many Requests"
 	case http.StatusInternalServerError:
 		return "Internal Server Error"
 	default:
@@ -31,19 +46,40 @@ func getMessage(status int) string {
 func Response(c echo.Context, status int, data interface{}, message string) error {
 	response := structs.JSONResponse{
 		ResponseCode:    status,
-		ResponseMessage: getMessage(status),
-		Message:         message,
+		ResponseMessage: T(c, getMessage(status)),
+		Message:         T(c, message),
 		Data:            data,
 	}
+	if status >= http.StatusBadRequest {
+		response.ErrorCode = apperrors.CodeFor(status)
+	}
 
 	return c.JSON(status, response)
 }
 
-func PageData(data interface{}, total int64) *structs.PagedData {
-	return &structs.PagedData{
-		List: data,
-		Meta: structs.MetaData{
-			Total: int(total),
-		},
+// PageData wraps a page loaded by Paginate with its metadata and the links to the pages around it
+func PageData(c echo.Context, data interface{}, page structs.Pagination) *structs.PagedData {
+	meta := structs.MetaData{PerPage: page.PerPage}
+	base := fmt.Sprintf("%s://%s%s", c.Scheme(), c.Request().Host, c.Request().URL.Path)
+	params := c.QueryParams()
+
+	if page.Cursor != nil {
+		meta.NextCursor = page.NextCursor
+		if page.NextCursor != "" {
+			meta.Links.Next = &LinksGenerator(base, params, "cursor", page.NextCursor)[0]
+		}
+		return &structs.PagedData{List: data, Meta: meta}
+	}
+
+	meta.Total = &page.Total
+	meta.Page = page.Page
+	meta.LastPage = max(1, int((page.Total+int64(page.PerPage)-1)/int64(page.PerPage)))
+	if page.Page < meta.LastPage {
+		meta.Links.Next = &LinksGenerator(base, params, "page", strconv.Itoa(page.Page+1))[0]
+	}
+	if page.Page > 1 {
+		// Past the end, prev leads back to the last page
+		meta.Links.Prev = &LinksGenerator(base, params, "page", strconv.Itoa(min(page.Page-1, meta.LastPage)))[0]
 	}
+	return &structs.PagedData{List: data, Meta: meta}
 }
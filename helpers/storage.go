@@ -0,0 +1,246 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"simple-crud-rnd/apperrors"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var ErrObjectNotFound = apperrors.New(http.StatusNotFound, "object_not_found", "object not found")
+
+// Storage hides where asset files live, keys are slash separated paths such as images/product_images/a.png
+type Storage interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	URL(key string) string
+}
+
+type (
+	LocalStorage struct {
+		root    string
+		baseURL string
+	}
+
+	S3Storage struct {
+		client  *minio.Client
+		bucket  string
+		baseURL string
+		timeout time.Duration
+	}
+
+	S3Options struct {
+		Endpoint  string
+		AccessKey string
+		SecretKey string
+		Bucket    string
+		Region    string
+		UseSSL    bool
+		// PublicURL is the base of the URLs returned to clients, defaults to <endpoint>/<bucket>
+		PublicURL string
+		// Timeout bounds every call to the bucket, reading an object included, defaults to a minute
+		Timeout time.Duration
+	}
+
+	// s3Object cancels the context of a read when the object is closed
+	s3Object struct {
+		*minio.Object
+		cancel context.CancelFunc
+	}
+)
+
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{root, strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+func (ls *LocalStorage) Put(key string, r io.Reader, size int64, contentType string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it, so concurrent writers of the same content
+	// never expose a half written file
+	f, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Chmod(0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (ls *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (ls *LocalStorage) Delete(key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (ls *LocalStorage) URL(key string) string {
+	return fmt.Sprintf("%s/%s", ls.baseURL, key)
+}
+
+// path keeps keys inside the storage root
+func (ls *LocalStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(ls.root, filepath.FromSlash(key)), nil
+}
+
+func NewS3Storage(options S3Options) (*S3Storage, error) {
+	client, err := minio.New(options.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(options.AccessKey, options.SecretKey, ""),
+		Secure: options.UseSSL,
+		Region: options.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, options.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, options.Bucket, minio.MakeBucketOptions{Region: options.Region}); err != nil {
+			return nil, err
+		}
+	}
+
+	baseURL := options.PublicURL
+	if baseURL == "" {
+		scheme := "http"
+		if options.UseSSL {
+			scheme = "https"
+		}
+		baseURL = fmt.Sprintf("%s://%s/%s", scheme, options.Endpoint, options.Bucket)
+	}
+	return &S3Storage{client, options.Bucket, strings.TrimSuffix(baseURL, "/"), timeout}, nil
+}
+
+func (s3 *S3Storage) Put(key string, r io.Reader, size int64, contentType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3.timeout)
+	defer cancel()
+
+	options := minio.PutObjectOptions{ContentType: contentType}
+	// The bucket serves the object itself, files other than images are downloaded like the local ones
+	if !strings.HasPrefix(key, "images/") {
+		options.ContentDisposition = "attachment"
+	}
+	_, err := s3.client.PutObject(ctx, s3.bucket, key, r, size, options)
+	return err
+}
+
+func (s3 *S3Storage) Get(key string) (io.ReadCloser, error) {
+	// The object is read after Get returns, the context lives until it is closed
+	ctx, cancel := context.WithTimeout(context.Background(), s3.timeout)
+	object, err := s3.client.GetObject(ctx, s3.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// GetObject is lazy, Stat surfaces a missing key before the caller starts reading
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		cancel()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return &s3Object{object, cancel}, nil
+}
+
+func (s3 *S3Storage) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3.timeout)
+	defer cancel()
+
+	return s3.client.RemoveObject(ctx, s3.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (so *s3Object) Close() error {
+	defer so.cancel()
+	return so.Object.Close()
+}
+
+func (s3 *S3Storage) URL(key string) string {
+	return fmt.Sprintf("%s/%s", s3.baseURL, (&url.URL{Path: key}).EscapedPath())
+}
+
+// StagedContent is validated content named by its SHA-256 hash that has not been written yet,
+// so the caller can look for an asset holding the same content first
+type StagedContent struct {
+	Hash        string
+	Path        string
+	ContentType string
+	Size        int64
+	// Variants are the sizes of the resized copies Write makes, nil for content other than images
+	Variants []int
+	write    func() error
+}
+
+// Write stores the content, writing identical content again is harmless
+func (sc *StagedContent) Write() error {
+	return sc.write()
+}
+
+// putBytes stores an in-memory file, which is how images and their variants are written
+func putBytes(storage Storage, key string, data []byte, contentType string) error {
+	return storage.Put(key, bytes.NewReader(data), int64(len(data)), contentType)
+}
@@ -0,0 +1,272 @@
+package helpers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is an in-memory bucket store answering the path style requests S3Storage makes
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]fakeObject
+	// stall holds object requests until the client gives up or the test ends
+	stall   bool
+	stopped chan struct{}
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, S3Options) {
+	t.Helper()
+	fake := &fakeS3{buckets: map[string]map[string]fakeObject{}, stopped: make(chan struct{})}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	// A handler stalled before reading the body does not notice the client leaving
+	t.Cleanup(func() { close(fake.stopped) })
+	return fake, S3Options{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		AccessKey: "access",
+		SecretKey: "secret",
+		Bucket:    "assets",
+		Region:    "us-east-1",
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if key != "" && f.stall {
+		f.mu.Unlock()
+		select {
+		case <-r.Context().Done():
+		case <-f.stopped:
+		}
+		f.mu.Lock()
+		return
+	}
+
+	objects, exists := f.buckets[bucket]
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case key == "" && r.Method == http.MethodPut:
+		f.buckets[bucket] = map[string]fakeObject{}
+	case !exists:
+		fakeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+	case r.Method == http.MethodPut:
+		data, err := fakeS3Body(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		objects[key] = fakeObject{data, r.Header.Get("Content-Type")}
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := objects[key]
+		if !ok {
+			fakeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", fmt.Sprint(len(object.data)))
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// fakeS3Body reads an upload, decoding the aws-chunked encoding of streaming signatures
+func fakeS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	data := []byte{}
+	body := bufio.NewReader(r.Body)
+	for {
+		header, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(strings.SplitN(header, ";", 2)[0]), 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(body, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func fakeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func readObject(t *testing.T, storage Storage, key string) string {
+	t.Helper()
+	r, err := storage.Get(key)
+	if err != nil {
+		t.Fatalf("get %s: %v", key, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+	return string(data)
+}
+
+// testStorage runs what every Storage has to do
+func testStorage(t *testing.T, storage Storage) {
+	const key = "images/product_images/a.png"
+	if err := storage.Put(key, strings.NewReader("content"), 7, "image/png"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if got := readObject(t, storage, key); got != "content" {
+		t.Errorf("got %q, want the content put", got)
+	}
+
+	if err := storage.Put(key, strings.NewReader("changed"), 7, "image/png"); err != nil {
+		t.Fatalf("put again: %v", err)
+	}
+	if got := readObject(t, storage, key); got != "changed" {
+		t.Errorf("got %q after putting the key again", got)
+	}
+
+	if err := storage.Delete(key); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := storage.Get(key); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("get of a deleted key returned %v, want ErrObjectNotFound", err)
+	}
+	if err := storage.Delete(key); err != nil {
+		t.Errorf("deleting a missing key failed: %v", err)
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "http://localhost/api/v1/assets/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStorage(t, storage)
+
+	if got := storage.URL("images/a.png"); got != "http://localhost/api/v1/assets/images/a.png" {
+		t.Errorf("URL returned %s", got)
+	}
+}
+
+func TestLocalStorageRejectsKeysOutsideRoot(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"../a.png", "/etc/passwd", "images/../../a.png"} {
+		if err := storage.Put(key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("put of %s was accepted", key)
+		}
+		if _, err := storage.Get(key); err == nil || errors.Is(err, ErrObjectNotFound) {
+			t.Errorf("get of %s returned %v, want an invalid key error", key, err)
+		}
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	fake, options := newFakeS3(t)
+	storage, err := NewS3Storage(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.buckets["assets"]; !ok {
+		t.Fatal("the missing bucket was not created")
+	}
+	testStorage(t, storage)
+
+	if err := storage.Put("a.png", strings.NewReader("x"), 1, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.buckets["assets"]["a.png"].contentType; got != "image/png" {
+		t.Errorf("stored with content type %q", got)
+	}
+}
+
+func TestS3StorageURL(t *testing.T) {
+	_, options := newFakeS3(t)
+	storage, err := NewS3Storage(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := storage.URL("files/a b.pdf"), fmt.Sprintf("http://%s/assets/files/a%%20b.pdf", options.Endpoint); got != want {
+		t.Errorf("URL returned %s, want %s", got, want)
+	}
+
+	options.PublicURL = "https://cdn.example.com/"
+	storage, err = NewS3Storage(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := storage.URL("files/a.pdf"); got != "https://cdn.example.com/files/a.pdf" {
+		t.Errorf("URL returned %s with a public URL", got)
+	}
+}
+
+func TestS3StorageTimesOut(t *testing.T) {
+	fake, options := newFakeS3(t)
+	options.Timeout = 100 * time.Millisecond
+	storage, err := NewS3Storage(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.mu.Lock()
+	fake.stall = true
+	fake.mu.Unlock()
+
+	calls := map[string]func() error{
+		"put": func() error { return storage.Put("a.png", strings.NewReader("x"), 1, "image/png") },
+		"get": func() error {
+			_, err := storage.Get("a.png")
+			return err
+		},
+		"delete": func() error { return storage.Delete("a.png") },
+	}
+	for name, call := range calls {
+		start := time.Now()
+		if err := call(); err == nil {
+			t.Errorf("%s of a stalled bucket succeeded", name)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s gave up after %s", name, elapsed)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"simple-crud-rnd/structs"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func GenerateAccessToken(user structs.User, secret []byte, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := structs.JWTUser{
+		Email:           user.Email,
+		ID:              user.ID,
+		UpdatedSecurity: user.UpdatedSecurity,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
+// GenerateRefreshToken returns an opaque token for the client and the hash that is stored in the database
+func GenerateRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"fmt"
+	"io/fs"
+	"simple-crud-rnd/lang"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/labstack/echo/v4"
+)
+
+// Languages lists the languages that have a translation catalog
+var Languages = []string{"en", "id"}
+
+// Translator picks the catalog used to answer a request, see Localize and UseLanguage
+type Translator struct {
+	universal *ut.UniversalTranslator
+	fallback  ut.Translator
+}
+
+// NewTranslator loads every catalog in the lang package, requests in an unsupported language get defaultLanguage
+func NewTranslator(defaultLanguage string) (*Translator, error) {
+	supported := []locales.Translator{en.New(), id.New()}
+	universal := ut.New(supported[0], supported...)
+
+	catalogs, err := fs.ReadDir(lang.Catalogs, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, catalog := range catalogs {
+		f, err := lang.Catalogs.Open(catalog.Name())
+		if err != nil {
+			return nil, err
+		}
+		err = universal.ImportByReader(ut.FormatJSON, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", catalog.Name(), err)
+		}
+	}
+	if err := universal.VerifyTranslations(); err != nil {
+		return nil, err
+	}
+
+	fallback, ok := universal.GetTranslator(defaultLanguage)
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q, use one of %s", defaultLanguage, strings.Join(Languages, ", "))
+	}
+	return &Translator{universal, fallback}, nil
+}
+
+// Find returns the catalog of the first supported language, en-US and en_US fall back to en
+func (t *Translator) Find(languages ...string) ut.Translator {
+	for _, language := range languages {
+		language = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(language)), "-", "_")
+		base, _, _ := strings.Cut(language, "_")
+		for _, candidate := range []string{language, base} {
+			if trans, ok := t.universal.GetTranslator(candidate); ok {
+				return trans
+			}
+		}
+	}
+	return t.fallback
+}
+
+// UseLanguage switches the language of the rest of the request, unsupported languages are ignored
+func UseLanguage(c echo.Context, languages ...string) {
+	translator, ok := c.Get("translator").(*Translator)
+	if !ok {
+		return
+	}
+
+	trans := translator.Find(languages...)
+	c.Set("locale", trans)
+	c.Response().Header().Set("Content-Language", trans.Locale())
+}
+
+// T translates a catalog key for the request, {0}, {1}... in the key are replaced by params.
+// Keys missing from the catalog, such as errors from the database driver, are returned as they are.
+func T(c echo.Context, key string, params ...string) string {
+	if trans, ok := c.Get("locale").(ut.Translator); ok {
+		return translate(trans, key, params...)
+	}
+	return translate(nil, key, params...)
+}
+
+func translate(trans ut.Translator, key string, params ...string) string {
+	if key == "" {
+		return key
+	}
+	if trans != nil {
+		if message, err := trans.T(key, params...); err == nil {
+			return message
+		}
+	}
+
+	for i, param := range params {
+		key = strings.ReplaceAll(key, "{"+strconv.Itoa(i)+"}", param)
+	}
+	return key
+}
+
+// AcceptLanguages returns the languages of an Accept-Language header, most preferred first
+func AcceptLanguages(header string) []string {
+	type weighted struct {
+		language string
+		quality  float64
+	}
+
+	accepted := []weighted{}
+	for _, part := range strings.Split(header, ",") {
+		language, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if language == "" || language == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			accepted = append(accepted, weighted{language, quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	languages := []string{}
+	for _, item := range accepted {
+		if !slices.Contains(languages, item.language) {
+			languages = append(languages, item.language)
+		}
+	}
+	return languages
+}
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"simple-crud-rnd/structs"
+)
+
+// UploadHelper keeps the chunks of unfinished uploads in a storage shared by every instance, one
+// object per chunk named by the offset it starts at
+type UploadHelper struct {
+	storage Storage
+}
+
+func NewUploadHelper(storage Storage) *UploadHelper {
+	return &UploadHelper{storage}
+}
+
+// Put stores the chunk received at the offset of the upload, replacing what a failed request left there
+func (uh *UploadHelper) Put(upload structs.AssetUpload, chunk []byte) error {
+	return putBytes(uh.storage, uh.key(upload, upload.Offset), chunk, "application/octet-stream")
+}
+
+// Open joins the chunks of the upload into a temporary file, which is removed when it is closed
+func (uh *UploadHelper) Open(upload structs.AssetUpload) (io.ReadSeekCloser, error) {
+	f, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	tmp := &tempFile{f}
+
+	for _, offset := range upload.Chunks {
+		if err := uh.copyChunk(tmp, uh.key(upload, offset)); err != nil {
+			tmp.Close()
+			return nil, err
+		}
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// Remove deletes every chunk of the upload, together with one a failed request may have left at its offset
+func (uh *UploadHelper) Remove(upload structs.AssetUpload) error {
+	for _, offset := range append(upload.Chunks, upload.Offset) {
+		if err := uh.storage.Delete(uh.key(upload, offset)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (uh *UploadHelper) key(upload structs.AssetUpload, offset int64) string {
+	return fmt.Sprintf("uploads/%s/%d", upload.ID, offset)
+}
+
+func (uh *UploadHelper) copyChunk(dst io.Writer, key string) error {
+	chunk, err := uh.storage.Get(key)
+	if err != nil {
+		return err
+	}
+	defer chunk.Close()
+
+	_, err = io.Copy(dst, chunk)
+	return err
+}
+
+// tempFile removes the file once it is closed
+type tempFile struct {
+	*os.File
+}
+
+func (tf *tempFile) Close() error {
+	err := tf.File.Close()
+	if removeErr := os.Remove(tf.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
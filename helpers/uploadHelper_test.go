@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"errors"
+	"io"
+	"simple-crud-rnd/structs"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUploadHelperJoinsChunks(t *testing.T) {
+	storage, err := NewLocalStorage(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadHelper := NewUploadHelper(storage)
+
+	upload := structs.AssetUpload{ID: uuid.New()}
+	for _, chunk := range []string{"hello ", "chunked ", "world"} {
+		if err := uploadHelper.Put(upload, []byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+		upload.Chunks = append(upload.Chunks, upload.Offset)
+		upload.Offset += int64(len(chunk))
+	}
+	// A chunk stored by a request that failed before the offset moved
+	if err := uploadHelper.Put(upload, []byte("orphan")); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := uploadHelper.Open(upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "hello chunked world" {
+			t.Fatalf("content = %q", data)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uploadHelper.Remove(upload); err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range append(upload.Chunks, upload.Offset) {
+		if _, err := storage.Get(uploadHelper.key(upload, offset)); !errors.Is(err, ErrObjectNotFound) {
+			t.Fatalf("chunk at %d: err = %v, want ErrObjectNotFound", offset, err)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package helpers
 
 import (
+	"reflect"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator"
 )
 
@@ -9,6 +13,18 @@ type Validator struct {
 }
 
 func NewValidator(validator *validator.Validate) *Validator {
+	// Report fields by their JSON name, which is what clients send
+	validator.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
 	return &Validator{
 		validator: validator,
 	}
@@ -20,3 +36,74 @@ func (cv *Validator) Validate(i interface{}) error {
 	}
 	return nil
 }
+
+// ValidationErrors maps every invalid field, such as phone_number or details[0].quantity, to its messages
+// translated with trans, which may be nil for English
+func ValidationErrors(err error, trans ut.Translator) (map[string][]string, bool) {
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return nil, false
+	}
+
+	errors := map[string][]string{}
+	for _, fieldError := range fieldErrors {
+		field := fieldPath(fieldError)
+		errors[field] = append(errors[field], validationMessage(trans, fieldError))
+	}
+	return errors, true
+}
+
+// fieldPath drops the name of the validated struct from the namespace, e.g. SaleRequest.details[0].quantity
+func fieldPath(fieldError validator.FieldError) string {
+	namespace := fieldError.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fieldError.Field()
+}
+
+func validationMessage(trans ut.Translator, fieldError validator.FieldError) string {
+	param := fieldError.Param()
+	switch fieldError.Tag() {
+	case "required":
+		return translate(trans, "This field is required")
+	case "email":
+		return translate(trans, "Must be a valid email address")
+	case "e164":
+		return translate(trans, "Must be a phone number in international format, e.g. +6281234567890")
+	case "uuid":
+		return translate(trans, "Must be a valid UUID")
+	case "oneof":
+		return translate(trans, "Must be one of {0}", strings.Join(strings.Fields(param), ", "))
+	case "min":
+		if isCountable(fieldError.Kind()) {
+			return translate(trans, "Must contain at least {0} items", param)
+		}
+		if fieldError.Kind() == reflect.String {
+			return translate(trans, "Must be at least {0} characters long", param)
+		}
+		return translate(trans, "Must be at least {0}", param)
+	case "max":
+		if isCountable(fieldError.Kind()) {
+			return translate(trans, "Must contain at most {0} items", param)
+		}
+		if fieldError.Kind() == reflect.String {
+			return translate(trans, "Must be at most {0} characters long", param)
+		}
+		return translate(trans, "Must be at most {0}", param)
+	case "gt":
+		return translate(trans, "Must be greater than {0}", param)
+	case "gte":
+		return translate(trans, "Must be greater than or equal to {0}", param)
+	case "lt":
+		return translate(trans, "Must be less than {0}", param)
+	case "lte":
+		return translate(trans, "Must be less than or equal to {0}", param)
+	default:
+		return translate(trans, "Failed the {0} validation", fieldError.Tag())
+	}
+}
+
+func isCountable(kind reflect.Kind) bool {
+	return kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
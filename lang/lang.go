@@ -0,0 +1,8 @@
+// Package lang holds the translation catalogs, one universal-translator JSON file per language.
+// Keys are the English messages, so a message missing from a catalog is still readable.
+package lang
+
+import "embed"
+
+//go:embed *.json
+var Catalogs embed.FS
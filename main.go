@@ -2,7 +2,9 @@ package main
 
 import (
 	"log"
+	"os"
 
+	"simple-crud-rnd/commands"
 	"simple-crud-rnd/config"
 	"simple-crud-rnd/routes"
 )
@@ -18,6 +20,13 @@ func main() {
 		log.Fatal("Error opening database")
 	}
 
+	if len(os.Args) > 1 {
+		if err := commands.Run(cfg, db, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	e := routes.NewHTTPServer(cfg, db)
 	e.RunHTTPServer()
 }
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AssetHeaders is put on the routes serving stored assets by key. Browsers must not sniff them into
+// another type, and files other than images are downloaded instead of opened on the API's origin.
+func AssetHeaders(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Response().Header()
+		header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+		if !strings.HasPrefix(strings.TrimPrefix(c.Param("*"), "/"), "images/") {
+			header.Set(echo.HeaderContentDisposition, "attachment")
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type AuthMiddleware struct {
+	userModel *models.UserModel
+}
+
+func NewAuthMiddleware(userModel *models.UserModel) *AuthMiddleware {
+	return &AuthMiddleware{
+		userModel: userModel,
+	}
+}
+
+// ValidateSecurity must run after echojwt. It rejects tokens issued before the user's
+// updated_security, so bumping that timestamp revokes every older session at once.
+func (am *AuthMiddleware) ValidateSecurity(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims, err := helpers.GetJWTUser(c)
+		if err != nil {
+			return helpers.Response(c, http.StatusUnauthorized, nil, err.Error())
+		}
+
+		// RequirePermission reads the role from the user stored here
+		user, err := am.userModel.GetById(claims.ID, structs.FieldSet{Preloads: []string{"Role"}})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return helpers.Response(c, http.StatusUnauthorized, nil, "User no longer exists")
+			}
+			return err
+		}
+
+		if user.LockedAt != nil {
+			return helpers.Response(c, http.StatusUnauthorized, nil, "Account is locked")
+		}
+
+		if claims.UpdatedSecurity.Before(user.UpdatedSecurity) {
+			return helpers.Response(c, http.StatusUnauthorized, nil, "Token has been revoked")
+		}
+
+		c.Set("auth_user", user)
+		c.SetRequest(c.Request().WithContext(helpers.WithActor(c.Request().Context(), user.ID)))
+		if user.Language != "" {
+			helpers.UseLanguage(c, user.Language)
+		}
+		return next(c)
+	}
+}
+
+// RequirePermission must run after ValidateSecurity. The role is loaded on every request,
+// so permission changes take effect without restarting the server.
+func (am *AuthMiddleware) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := c.Get("auth_user").(structs.User)
+			if !ok {
+				return helpers.Response(c, http.StatusUnauthorized, nil, "Missing authenticated user")
+			}
+
+			if user.Role == nil || !user.Role.Permissions.Has(permission) {
+				return helpers.Response(c, http.StatusForbidden, nil, helpers.T(c, "Missing permission {0}", permission))
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/labstack/echo/v4"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testJWTSecret = []byte("test-secret")
+
+// userDB is a dry run database answering every read of a user with stored, role included, or not
+// found when it is nil
+func userDB(t *testing.T, stored *structs.User) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun: true, SkipDefaultTransaction: true, DisableAutomaticPing: true, Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// After the preload, which finds no role in a dry run
+	err = db.Callback().Query().After("gorm:preload").Register("test:user", func(tx *gorm.DB) {
+		user, ok := tx.Statement.Dest.(*structs.User)
+		if !ok {
+			return
+		}
+		if stored == nil {
+			tx.AddError(gorm.ErrRecordNotFound)
+			return
+		}
+		*user = *stored
+		tx.RowsAffected = 1
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// accessToken signs a token the way Login does for the user as it was read then
+func accessToken(t *testing.T, user structs.User) string {
+	t.Helper()
+	token, err := helpers.GenerateAccessToken(user, testJWTSecret, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// authenticate sends a request with the token through echojwt, ValidateSecurity and then m
+func authenticate(t *testing.T, stored *structs.User, token string, m ...echo.MiddlewareFunc) (*httptest.ResponseRecorder, structs.JSONResponse) {
+	t.Helper()
+	am := NewAuthMiddleware(models.NewUserModel(userDB(t, stored)))
+
+	e := echo.New()
+	e.Use(echojwt.WithConfig(echojwt.Config{
+		NewClaimsFunc: func(c echo.Context) jwt.Claims {
+			return new(structs.JWTUser)
+		},
+		SigningKey: testJWTSecret,
+	}), am.ValidateSecurity)
+	e.GET("/", func(c echo.Context) error {
+		return helpers.Response(c, http.StatusOK, nil, "")
+	}, m...)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	response := structs.JSONResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s: %v", rec.Body.String(), err)
+	}
+	return rec, response
+}
+
+func TestValidateSecurity(t *testing.T) {
+	// MySQL keeps milliseconds of updated_security, so does the user a token is issued for
+	issued := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	user := structs.User{ID: uuid.New(), Email: "user@example.com", UpdatedSecurity: issued}
+	locked := time.Now()
+
+	tests := []struct {
+		name    string
+		stored  *structs.User
+		status  int
+		message string
+	}{
+		{
+			name:   "token issued after the last security change",
+			stored: &user,
+			status: http.StatusOK,
+		},
+		{
+			name:    "security changed after the token was issued",
+			stored:  &structs.User{ID: user.ID, UpdatedSecurity: issued.Add(time.Minute)},
+			status:  http.StatusUnauthorized,
+			message: "Token has been revoked",
+		},
+		{
+			name:    "security changed in the same second",
+			stored:  &structs.User{ID: user.ID, UpdatedSecurity: issued.Add(time.Millisecond)},
+			status:  http.StatusUnauthorized,
+			message: "Token has been revoked",
+		},
+		{
+			name:    "locked user",
+			stored:  &structs.User{ID: user.ID, UpdatedSecurity: issued, LockedAt: &locked},
+			status:  http.StatusUnauthorized,
+			message: "Account is locked",
+		},
+		{
+			name:    "deleted user",
+			status:  http.StatusUnauthorized,
+			message: "User no longer exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, response := authenticate(t, tt.stored, accessToken(t, user))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.message != "" && response.Message != tt.message {
+				t.Errorf("message = %q, want %q", response.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions structs.Permissions
+		noRole      bool
+		status      int
+	}{
+		{name: "granted", permissions: structs.Permissions{"sales.view", "sales.create"}, status: http.StatusOK},
+		{name: "missing", permissions: structs.Permissions{"sales.view"}, status: http.StatusForbidden},
+		{name: "empty role", permissions: structs.Permissions{}, status: http.StatusForbidden},
+		{name: "wildcard", permissions: structs.Permissions{structs.PermissionAll}, status: http.StatusOK},
+		{name: "wildcard among others", permissions: structs.Permissions{"sales.view", structs.PermissionAll}, status: http.StatusOK},
+		{name: "prefix is not a wildcard", permissions: structs.Permissions{"sales.*", "sales"}, status: http.StatusForbidden},
+		{name: "no role", noRole: true, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := structs.User{ID: uuid.New(), UpdatedSecurity: time.Now().Truncate(time.Millisecond)}
+			if !tt.noRole {
+				role := structs.UserRole{ID: uuid.New(), Permissions: tt.permissions}
+				user.UserRolesId = role.ID.String()
+				user.Role = &role
+			}
+			am := NewAuthMiddleware(nil)
+
+			rec, response := authenticate(t, &user, accessToken(t, user), am.RequirePermission("sales.create"))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusForbidden && response.Message != "Missing permission sales.create" {
+				t.Errorf("message = %q", response.Message)
+			}
+		})
+	}
+}
+
+func TestRequirePermissionNeedsValidateSecurity(t *testing.T) {
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, NewAuthMiddleware(nil).RequirePermission("sales.view"))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d without an authenticated user, want 401", rec.Code)
+	}
+}
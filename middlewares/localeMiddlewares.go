@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"simple-crud-rnd/helpers"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Localize answers in the language of the Accept-Language header. ValidateSecurity switches
+// to the language saved by the authenticated user, when there is one.
+func Localize(translator *helpers.Translator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("translator", translator)
+			c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+			helpers.UseLanguage(c, helpers.AcceptLanguages(c.Request().Header.Get("Accept-Language"))...)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"fmt"
+	"regexp"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// requestIdPattern is what an X-Request-ID sent by the client must look like to be kept
+var requestIdPattern = regexp.MustCompile(fmt.Sprintf(`^[A-Za-z0-9._:-]{1,%d}$`, structs.AuditRequestIdLength))
+
+// RequestID answers the X-Request-ID of the request, or a new UUID when the request has none or
+// one that does not fit the audit log, being too long or holding other characters than letters,
+// digits and ._:-
+func RequestID() echo.MiddlewareFunc {
+	requestId := middleware.RequestIDWithConfig(middleware.RequestIDConfig{Generator: uuid.NewString})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		next = requestId(next)
+		return func(c echo.Context) error {
+			header := c.Request().Header
+			if id := header.Get(echo.HeaderXRequestID); id != "" && !requestIdPattern.MatchString(id) {
+				header.Del(echo.HeaderXRequestID)
+			}
+			return next(c)
+		}
+	}
+}
+
+// RequestInfo puts the client IP and the request ID in the request context, where the AuditLogs
+// plugin reads them. It must run after RequestID, which also answers the ID.
+func RequestInfo(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		info := helpers.RequestInfo{
+			IP:        c.RealIP(),
+			RequestId: c.Response().Header().Get(echo.HeaderXRequestID),
+		}
+		c.SetRequest(c.Request().WithContext(helpers.WithRequestInfo(c.Request().Context(), info)))
+		return next(c)
+	}
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// requestInfo runs a request with the X-Request-ID header through RequestID and RequestInfo
+func requestInfo(t *testing.T, requestId string) (helpers.RequestInfo, string) {
+	t.Helper()
+	e := echo.New()
+	e.Use(RequestID(), RequestInfo)
+
+	var info helpers.RequestInfo
+	e.GET("/", func(c echo.Context) error {
+		info = helpers.RequestInfoFrom(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if requestId != "" {
+		req.Header.Set(echo.HeaderXRequestID, requestId)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return info, rec.Header().Get(echo.HeaderXRequestID)
+}
+
+func TestRequestIDKeepsValidIDs(t *testing.T) {
+	for _, requestId := range []string{"abc-123", "trace.id_1:2", strings.Repeat("a", structs.AuditRequestIdLength)} {
+		info, answered := requestInfo(t, requestId)
+		if info.RequestId != requestId || answered != requestId {
+			t.Errorf("%q was stored as %q and answered as %q", requestId, info.RequestId, answered)
+		}
+	}
+}
+
+func TestRequestIDReplacesInvalidIDs(t *testing.T) {
+	tests := map[string]string{
+		"missing":            "",
+		"longer than column": strings.Repeat("a", 500),
+		"markup":             "<script>",
+		"spaces":             "a b",
+		"not ascii":          "ïd",
+	}
+	for name, requestId := range tests {
+		info, answered := requestInfo(t, requestId)
+		if _, err := uuid.Parse(info.RequestId); err != nil {
+			t.Errorf("%s: stored %q, want a generated UUID", name, info.RequestId)
+		}
+		if answered != info.RequestId {
+			t.Errorf("%s: answered %q, stored %q", name, answered, info.RequestId)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/url"
+	"simple-crud-rnd/helpers"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// VerifySignedURL only lets requests through when the path carries a valid, unexpired signature
+func VerifySignedURL(secret []byte) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key, err := url.PathUnescape(c.Param("*"))
+			if err != nil {
+				return helpers.Response(c, http.StatusBadRequest, nil, "Invalid asset path")
+			}
+
+			expires, err := strconv.ParseInt(c.QueryParam("expires"), 10, 64)
+			if err != nil {
+				return helpers.Response(c, http.StatusForbidden, nil, "Missing or invalid signature")
+			}
+
+			if !helpers.VerifyAssetSignature(key, expires, c.QueryParam("signature"), secret) {
+				return helpers.Response(c, http.StatusForbidden, nil, "Missing, invalid or expired signature")
+			}
+
+			return next(c)
+		}
+	}
+}
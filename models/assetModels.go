@@ -0,0 +1,232 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/structs"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAssetNotFound = apperrors.New(http.StatusBadRequest, "asset_not_found", "asset not found")
+
+type AssetModel struct {
+	db *gorm.DB
+}
+
+func NewAssetModel(db *gorm.DB) *AssetModel {
+	return &AssetModel{
+		db: db,
+	}
+}
+
+func (am *AssetModel) GetById(id uuid.UUID) (structs.Asset, error) {
+	asset := structs.Asset{}
+	err := am.db.First(&asset, id).Error
+	return asset, err
+}
+
+// GetVariants returns the variant sizes recorded on the assets stored at the paths, keyed by path
+func (am *AssetModel) GetVariants(category string, paths []string) (map[string][]int, error) {
+	variants := map[string][]int{}
+	if len(paths) == 0 {
+		return variants, nil
+	}
+
+	assets := []structs.Asset{}
+	if err := am.db.Select("path", "variants").Where("category = ? AND path IN ?", category, paths).Find(&assets).Error; err != nil {
+		return nil, err
+	}
+	for _, asset := range assets {
+		variants[asset.Path] = asset.Variants
+	}
+	return variants, nil
+}
+
+func (am *AssetModel) Create(ctx context.Context, asset *structs.Asset) error {
+	return am.db.WithContext(ctx).Create(asset).Error
+}
+
+// Reserve loads the asset the uploader already has for the same content in the category into asset, or
+// creates it. An unreferenced asset starts a new grace period, so the garbage collector does not delete
+// the file the caller is about to reuse. Call it before writing the content, which other uploaders'
+// assets may share.
+func (am *AssetModel) Reserve(ctx context.Context, asset *structs.Asset) error {
+	db := am.db.WithContext(ctx)
+	now := time.Now()
+	err := db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"released_at": gorm.Expr("CASE WHEN reference_count = 0 THEN ? ELSE NULL END", now),
+			"updated_at":  now,
+		}),
+	}).Create(asset).Error
+	if err != nil {
+		return err
+	}
+
+	// On a duplicate the insert turned into an update, read back the row that won
+	stored := structs.Asset{}
+	err = db.Where(map[string]interface{}{
+		"category":   asset.Category,
+		"hash":       asset.Hash,
+		"created_by": asset.CreatedBy,
+	}).First(&stored).Error
+	if err != nil {
+		return err
+	}
+	*asset = stored
+	return nil
+}
+
+// GetUnreferenced lists assets without any reference whose last use is older than the cutoff
+func (am *AssetModel) GetUnreferenced(cutoff time.Time) ([]structs.Asset, error) {
+	assets := []structs.Asset{}
+	err := unreferenced(am.db, cutoff).Find(&assets).Error
+	return assets, err
+}
+
+// Purge deletes the asset if it is still unreferenced and older than the cutoff, calling remove to
+// delete its files first unless another asset shares them. The row and the assets sharing its path
+// stay locked meanwhile, so a concurrent Reserve of the same content waits and then writes the file
+// again. It reports whether the asset was deleted.
+func (am *AssetModel) Purge(ctx context.Context, id uuid.UUID, cutoff time.Time, remove func(structs.Asset) error) (bool, error) {
+	purged := false
+	err := am.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		asset := structs.Asset{}
+		err := unreferenced(tx, cutoff).Clauses(clause.Locking{Strength: "UPDATE"}).First(&asset, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var sharing int64
+		if err := tx.Model(&structs.Asset{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("path = ? AND id <> ?", asset.Path, asset.ID).Count(&sharing).Error; err != nil {
+			return err
+		}
+		if sharing == 0 {
+			if err := remove(asset); err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&asset).Error; err != nil {
+			return err
+		}
+		purged = true
+		return nil
+	})
+	return purged, err
+}
+
+// SyncReferenceCounts recomputes every reference_count from m_asset_references. Counts changing
+// meanwhile may be lost, nothing else should be writing assets while it runs.
+func (am *AssetModel) SyncReferenceCounts(ctx context.Context) error {
+	return am.db.WithContext(ctx).Exec("UPDATE m_assets SET reference_count = (SELECT COUNT(*) FROM m_asset_references r WHERE r.asset_id = m_assets.id)").Error
+}
+
+func unreferenced(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	return db.
+		Where("reference_count = 0").
+		Where("COALESCE(released_at, created_at) < ?", cutoff)
+}
+
+// assetField is an image field of a model, the assets it references are uploaded to Category
+type assetField struct {
+	Table    string
+	Field    string
+	Category string
+}
+
+// withAsset runs write, which returns the ID of the row it wrote, in one transaction with
+// attaching the asset to the field of that row. write gets the path of the asset to store, or an
+// empty path when no asset is given. The asset is share-locked until the commit so the garbage
+// collector can not purge it in between, and a failing attach rolls the write back, so a retry
+// does not create the row twice.
+func withAsset(db *gorm.DB, field assetField, assetId *uuid.UUID, write func(tx *gorm.DB, path string) (uuid.UUID, error)) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		path := ""
+		if assetId != nil {
+			asset := structs.Asset{}
+			err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("category = ?", field.Category).First(&asset, *assetId).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAssetNotFound
+			}
+			if err != nil {
+				return err
+			}
+			path = asset.Path
+		}
+
+		ownerId, err := write(tx, path)
+		if err != nil || assetId == nil {
+			return err
+		}
+		return attach(tx, field.Table, ownerId, field.Field, *assetId)
+	})
+}
+
+func attach(tx *gorm.DB, ownerTable string, ownerId uuid.UUID, field string, assetId uuid.UUID) error {
+	reference := structs.AssetReference{}
+	err := tx.Where("owner_table = ? AND owner_id = ? AND field = ?", ownerTable, ownerId, field).First(&reference).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		if reference.AssetId == assetId {
+			return nil
+		}
+		if err := tx.Delete(&reference).Error; err != nil {
+			return err
+		}
+		if err := release(tx, reference.AssetId); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Create(&structs.AssetReference{
+		AssetId:    assetId,
+		OwnerTable: ownerTable,
+		OwnerId:    ownerId,
+		Field:      field,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&structs.Asset{}).Where("id = ?", assetId).Updates(map[string]interface{}{
+		"reference_count": gorm.Expr("reference_count + 1"),
+		"released_at":     nil,
+	}).Error
+}
+
+// releaseOwner drops every reference held by the owner, e.g. when the row is deleted
+func releaseOwner(tx *gorm.DB, ownerTable string, ownerId uuid.UUID) error {
+	references := []structs.AssetReference{}
+	if err := tx.Where("owner_table = ? AND owner_id = ?", ownerTable, ownerId).Find(&references).Error; err != nil {
+		return err
+	}
+
+	for _, reference := range references {
+		if err := tx.Delete(&reference).Error; err != nil {
+			return err
+		}
+		if err := release(tx, reference.AssetId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// release drops one reference and stamps the asset when the last one is gone, which starts the grace period
+func release(tx *gorm.DB, assetId uuid.UUID) error {
+	// MySQL applies the assignments left to right, released_at must see the count before the decrement
+	return tx.Exec(
+		"UPDATE m_assets SET released_at = CASE WHEN reference_count <= 1 THEN ? ELSE released_at END, reference_count = GREATEST(reference_count, 1) - 1 WHERE id = ?",
+		time.Now(), assetId,
+	).Error
+}
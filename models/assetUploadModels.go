@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/structs"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUploadOffsetMismatch = apperrors.New(http.StatusConflict, "upload_offset_mismatch", "upload offset does not match")
+
+type AssetUploadModel struct {
+	db *gorm.DB
+}
+
+func NewAssetUploadModel(db *gorm.DB) *AssetUploadModel {
+	return &AssetUploadModel{
+		db: db,
+	}
+}
+
+func (aum *AssetUploadModel) GetById(id uuid.UUID) (structs.AssetUpload, error) {
+	upload := structs.AssetUpload{}
+	err := aum.db.First(&upload, id).Error
+	return upload, err
+}
+
+func (aum *AssetUploadModel) Create(ctx context.Context, upload *structs.AssetUpload) error {
+	return aum.db.WithContext(ctx).Create(upload).Error
+}
+
+// Append stores a chunk with the upload row locked, so the chunks of one upload are taken one at a
+// time whichever instance receives them. write gets the upload as stored and returns how many bytes
+// it stored at its offset, the offset then moves past them and the expiry is extended. The upload is
+// returned as read when write fails.
+func (aum *AssetUploadModel) Append(ctx context.Context, id uuid.UUID, expiresAt time.Time, write func(upload structs.AssetUpload) (int64, error)) (structs.AssetUpload, error) {
+	upload := structs.AssetUpload{}
+	err := aum.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&upload, id).Error; err != nil {
+			return err
+		}
+
+		written, err := write(upload)
+		if err != nil || written == 0 {
+			return err
+		}
+		updated := upload
+		updated.Chunks = append(updated.Chunks, updated.Offset)
+		updated.Offset += written
+		updated.ExpiresAt = expiresAt
+		if err := tx.Select("chunks", "upload_offset", "expires_at").Updates(&updated).Error; err != nil {
+			return err
+		}
+		upload = updated
+		return nil
+	})
+	return upload, err
+}
+
+func (aum *AssetUploadModel) GetExpired(now time.Time) ([]structs.AssetUpload, error) {
+	uploads := []structs.AssetUpload{}
+	err := aum.db.Where("expires_at < ?", now).Find(&uploads).Error
+	return uploads, err
+}
+
+func (aum *AssetUploadModel) Delete(ctx context.Context, id uuid.UUID) error {
+	return aum.db.WithContext(ctx).Delete(&structs.AssetUpload{}, id).Error
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditLogModel struct {
+	db *gorm.DB
+}
+
+func NewAuditLogModel(db *gorm.DB) *AuditLogModel {
+	return &AuditLogModel{
+		db: db,
+	}
+}
+
+func (alm *AuditLogModel) GetAll(page *structs.Pagination, list structs.ListQuery) ([]structs.AuditLog, error) {
+	logs := []structs.AuditLog{}
+	query := helpers.ApplyFilters(alm.db.Model(&structs.AuditLog{}), list)
+
+	if err := helpers.Paginate(query, page, list, &logs); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (alm *AuditLogModel) GetById(id uuid.UUID) (structs.AuditLog, error) {
+	log := structs.AuditLog{}
+	err := alm.db.First(&log, id).Error
+	return log, err
+}
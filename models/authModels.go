@@ -0,0 +1,68 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"simple-crud-rnd/structs"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuthModel struct {
+	db *gorm.DB
+}
+
+func NewAuthModel(db *gorm.DB) *AuthModel {
+	return &AuthModel{
+		db: db,
+	}
+}
+
+func (am *AuthModel) CreateRefreshToken(ctx context.Context, userId uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	refreshToken := structs.RefreshToken{
+		UserID:    userId,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}
+	return am.db.WithContext(ctx).Create(&refreshToken).Error
+}
+
+// ConsumeRefreshToken deletes the refresh token so it can only be used once
+func (am *AuthModel) ConsumeRefreshToken(ctx context.Context, tokenHash string) (structs.RefreshToken, error) {
+	refreshToken := structs.RefreshToken{}
+	err := am.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ?", tokenHash).First(&refreshToken).Error; err != nil {
+			return err
+		}
+
+		res := tx.Delete(&structs.RefreshToken{}, refreshToken.ID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return refreshToken, err
+	}
+
+	if refreshToken.ExpiresAt.Before(time.Now()) {
+		return refreshToken, errors.New("refresh token expired")
+	}
+	return refreshToken, nil
+}
+
+func (am *AuthModel) DeleteRefreshToken(ctx context.Context, tokenHash string) error {
+	res := am.db.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&structs.RefreshToken{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// memoryAuth follows the statements a dry run database builds for the refresh tokens and the user,
+// so a test can check what the models would have read back
+type memoryAuth struct {
+	user   structs.User
+	tokens map[string]structs.RefreshToken
+}
+
+func newMemoryAuth(t *testing.T, user structs.User) (*gorm.DB, *memoryAuth) {
+	t.Helper()
+	db := dryRunDB(t)
+	// Tokens that are not found are part of the tests
+	db.Logger = logger.Discard
+	ma := &memoryAuth{user: user, tokens: map[string]structs.RefreshToken{}}
+	for name, err := range map[string]error{
+		"create": db.Callback().Create().After("gorm:create").Register("test:memory", ma.create),
+		"query":  db.Callback().Query().After("gorm:query").Register("test:memory", ma.query),
+		"update": db.Callback().Update().After("gorm:update").Register("test:memory", ma.update),
+		"delete": db.Callback().Delete().After("gorm:delete").Register("test:memory", ma.delete),
+	} {
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	return db, ma
+}
+
+func (ma *memoryAuth) create(tx *gorm.DB) {
+	if token, ok := tx.Statement.Dest.(*structs.RefreshToken); ok {
+		ma.tokens[token.TokenHash] = *token
+		tx.RowsAffected = 1
+	}
+}
+
+func (ma *memoryAuth) query(tx *gorm.DB) {
+	switch dest := tx.Statement.Dest.(type) {
+	case *structs.RefreshToken:
+		token, ok := ma.tokenOf(tx)
+		if !ok {
+			tx.AddError(gorm.ErrRecordNotFound)
+			return
+		}
+		*dest = token
+	case *structs.User:
+		*dest = ma.user
+	default:
+		return
+	}
+	tx.RowsAffected = 1
+}
+
+// tokenOf finds the token whose hash is a value of the statement
+func (ma *memoryAuth) tokenOf(tx *gorm.DB) (structs.RefreshToken, bool) {
+	for _, v := range tx.Statement.Vars {
+		if token, ok := ma.tokens[fmt.Sprint(v)]; ok {
+			return token, true
+		}
+	}
+	return structs.RefreshToken{}, false
+}
+
+func (ma *memoryAuth) update(tx *gorm.DB) {
+	if tx.Statement.Table == ma.user.TableName() && ma.matches(tx, ma.user.ID.String()) {
+		tx.RowsAffected = 1
+	}
+}
+
+// delete removes the tokens whose id, user or hash is a value of the statement
+func (ma *memoryAuth) delete(tx *gorm.DB) {
+	if tx.Statement.Table != (structs.RefreshToken{}).TableName() {
+		return
+	}
+	for hash, token := range ma.tokens {
+		if ma.matches(tx, token.ID.String(), token.UserID.String(), token.TokenHash) {
+			delete(ma.tokens, hash)
+			tx.RowsAffected++
+		}
+	}
+}
+
+func (ma *memoryAuth) matches(tx *gorm.DB, values ...string) bool {
+	for _, v := range tx.Statement.Vars {
+		for _, value := range values {
+			if fmt.Sprint(v) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// userTokens counts the refresh tokens left to the user
+func (ma *memoryAuth) userTokens(userId uuid.UUID) int {
+	count := 0
+	for _, token := range ma.tokens {
+		if token.UserID == userId {
+			count++
+		}
+	}
+	return count
+}
+
+func TestConsumeRefreshTokenRotates(t *testing.T) {
+	ctx := context.Background()
+	db, ma := newMemoryAuth(t, structs.User{ID: uuid.New()})
+	am := NewAuthModel(db)
+
+	_, hash, err := helpers.GenerateRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.CreateRefreshToken(ctx, ma.user.ID, hash, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := am.ConsumeRefreshToken(ctx, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.UserID != ma.user.ID {
+		t.Errorf("token of %s, want %s", token.UserID, ma.user.ID)
+	}
+	if len(ma.tokens) != 0 {
+		t.Errorf("%d tokens left after the refresh, the consumed one must be deleted", len(ma.tokens))
+	}
+
+	// A token replayed after it was rotated is refused
+	if _, err := am.ConsumeRefreshToken(ctx, hash); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("reused token: err = %v, want record not found", err)
+	}
+}
+
+func TestConsumeRefreshTokenRefusesExpired(t *testing.T) {
+	ctx := context.Background()
+	db, ma := newMemoryAuth(t, structs.User{ID: uuid.New()})
+	am := NewAuthModel(db)
+
+	if err := am.CreateRefreshToken(ctx, ma.user.ID, "expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.ConsumeRefreshToken(ctx, "expired"); err == nil {
+		t.Error("an expired token is accepted")
+	}
+	if len(ma.tokens) != 0 {
+		t.Error("an expired token is kept once presented")
+	}
+}
+
+func TestSecurityChangesRevokeRefreshTokens(t *testing.T) {
+	password, err := helpers.PasswordHash("old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]func(um *UserModel, ctx context.Context, id uuid.UUID) error{
+		"password change": func(um *UserModel, ctx context.Context, id uuid.UUID) error {
+			return um.ChangePassword(ctx, id, "old-password", "new-password")
+		},
+		"lock":          (*UserModel).Lock,
+		"logout of all": (*UserModel).BumpSecurity,
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			db, ma := newMemoryAuth(t, structs.User{ID: uuid.New(), Password: password})
+			statements := recordSQL(t, db)
+			am := NewAuthModel(db)
+
+			other := uuid.New()
+			for i, userId := range []uuid.UUID{ma.user.ID, ma.user.ID, other} {
+				if err := am.CreateRefreshToken(ctx, userId, fmt.Sprint("token-", i), time.Now().Add(time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := change(NewUserModel(db), ctx, ma.user.ID); err != nil {
+				t.Fatal(err)
+			}
+			if n := ma.userTokens(ma.user.ID); n != 0 {
+				t.Errorf("%d refresh tokens of the user survive", n)
+			}
+			if n := ma.userTokens(other); n != 1 {
+				t.Errorf("%d refresh tokens of another user left, want 1", n)
+			}
+
+			bumped := false
+			for _, statement := range *statements {
+				bumped = bumped || containsAll(statement, "UPDATE `m_user` ", "`updated_security`=")
+			}
+			if !bumped {
+				t.Errorf("updated_security is not moved by %q", *statements)
+			}
+		})
+	}
+}
+
+func containsAll(s string, parts ...string) bool {
+	for _, part := range parts {
+		if !strings.Contains(s, part) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"context"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var customerPhoto = assetField{Table: structs.Customer{}.TableName(), Field: "photo", Category: structs.CustomerPhotos}
+
+type CustomerModel struct {
+	db *gorm.DB
+}
+
+func NewCustomerModel(db *gorm.DB) *CustomerModel {
+	return &CustomerModel{
+		db: db,
+	}
+}
+
+// GetAll searches name, email and phone number with the same keyword
+func (cm *CustomerModel) GetAll(page *structs.Pagination, search string, list structs.ListQuery, fields structs.FieldSet) ([]structs.Customer, error) {
+	customers := []structs.Customer{}
+	query := cm.db.Model(&structs.Customer{})
+	if search != "" {
+		keyword := "%" + search + "%"
+		query = query.Where("name LIKE ? OR email LIKE ? OR phone_number LIKE ?", keyword, keyword, keyword)
+	}
+	query = helpers.ApplyFilters(query, list)
+
+	if err := helpers.Paginate(helpers.SelectFields(query, structs.CustomerQuery, fields), page, list, &customers, fields.Preloads...); err != nil {
+		return nil, err
+	}
+
+	return customers, nil
+}
+
+func (cm *CustomerModel) GetById(id uuid.UUID, fields structs.FieldSet) (structs.Customer, error) {
+	customer := structs.Customer{}
+	err := helpers.Preload(helpers.SelectFields(cm.db, structs.CustomerQuery, fields), fields.Preloads...).First(&customer, id).Error
+	return customer, err
+}
+
+func (cm *CustomerModel) Create(ctx context.Context, payload *structs.CustomerRequest) (structs.Customer, error) {
+	customer := structs.Customer{
+		Name:        payload.Name,
+		Email:       payload.Email,
+		PhoneNumber: payload.PhoneNumber,
+		Address:     payload.Address,
+	}
+	err := withAsset(cm.db.WithContext(ctx), customerPhoto, payload.PhotoAssetId, func(tx *gorm.DB, photo string) (uuid.UUID, error) {
+		customer.Photo = photo
+		err := tx.Create(&customer).Error
+		return customer.ID, err
+	})
+	return customer, err
+}
+
+func (cm *CustomerModel) Update(ctx context.Context, payload *structs.CustomerRequest) (structs.Customer, error) {
+	db := cm.db.WithContext(ctx)
+	customer := structs.Customer{}
+	if err := db.First(&customer, payload.ID).Error; err != nil {
+		return customer, err
+	}
+
+	customer.Name = payload.Name
+	customer.Email = payload.Email
+	customer.PhoneNumber = payload.PhoneNumber
+	customer.Address = payload.Address
+	columns := []string{"name", "email", "phone_number", "address"}
+
+	err := withAsset(db, customerPhoto, payload.PhotoAssetId, func(tx *gorm.DB, photo string) (uuid.UUID, error) {
+		if photo != "" {
+			customer.Photo = photo
+			columns = append(columns, "photo")
+		}
+		return customer.ID, tx.Select(columns).Updates(&customer).Error
+	})
+	return customer, err
+}
+
+func (cm *CustomerModel) Delete(ctx context.Context, id uuid.UUID) error {
+	return softDelete(cm.db.WithContext(ctx), &structs.Customer{}, id)
+}
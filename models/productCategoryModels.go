@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCategoryInUse = apperrors.New(http.StatusConflict, "category_in_use", "Product category still has products, move or delete them first")
+
+type ProductCategoryModel struct {
+	db *gorm.DB
+}
+
+func NewProductCategoryModel(db *gorm.DB) *ProductCategoryModel {
+	return &ProductCategoryModel{
+		db: db,
+	}
+}
+
+func (pcm *ProductCategoryModel) GetAll(page *structs.Pagination, search string) ([]structs.ProductCategory, error) {
+	categories := []structs.ProductCategory{}
+	query := pcm.db.Model(&structs.ProductCategory{})
+	if search != "" {
+		query = query.Where("name LIKE ?", "%"+search+"%")
+	}
+
+	if err := helpers.Paginate(query, page, byName, &categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (pcm *ProductCategoryModel) GetById(id uuid.UUID) (structs.ProductCategory, error) {
+	category := structs.ProductCategory{}
+	err := pcm.db.First(&category, id).Error
+	return category, err
+}
+
+func (pcm *ProductCategoryModel) Create(ctx context.Context, payload *structs.ProductCategoryRequest) (structs.ProductCategory, error) {
+	category := structs.ProductCategory{
+		Name:        payload.Name,
+		Description: payload.Description,
+	}
+	err := pcm.db.WithContext(ctx).Create(&category).Error
+	return category, err
+}
+
+func (pcm *ProductCategoryModel) Update(ctx context.Context, payload *structs.ProductCategoryRequest) (structs.ProductCategory, error) {
+	db := pcm.db.WithContext(ctx)
+	category := structs.ProductCategory{}
+	if err := db.First(&category, payload.ID).Error; err != nil {
+		return category, err
+	}
+
+	category.Name = payload.Name
+	category.Description = payload.Description
+	err := db.Select("name", "description").Updates(&category).Error
+	return category, err
+}
+
+// Delete refuses to remove a category that is still referenced by a product, trashed products
+// included since they can be restored into it. The category stays locked until the delete, so a
+// product can not be moved into it in between, see ProductModel.lockCategory.
+func (pcm *ProductCategoryModel) Delete(ctx context.Context, id uuid.UUID) error {
+	return pcm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		category := structs.ProductCategory{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, id).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Unscoped().Model(&structs.Product{}).Where("product_category_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+
+		return tx.Delete(&category).Error
+	})
+}
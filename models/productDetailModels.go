@@ -0,0 +1,119 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductNotFound   = apperrors.New(http.StatusNotFound, "product_not_found", "product not found")
+	ErrInsufficientStock = apperrors.New(http.StatusConflict, "insufficient_stock", "insufficient stock")
+	ErrNegativePrice     = apperrors.New(http.StatusBadRequest, "negative_price", "price_delta can not bring the price below zero")
+)
+
+type ProductDetailModel struct {
+	db *gorm.DB
+}
+
+func NewProductDetailModel(db *gorm.DB) *ProductDetailModel {
+	return &ProductDetailModel{
+		db: db,
+	}
+}
+
+func (pdm *ProductDetailModel) GetByProduct(productId uuid.UUID) ([]structs.ProductDetail, error) {
+	details := []structs.ProductDetail{}
+	err := pdm.db.Where("product_id = ?", productId).Order("type, description").Find(&details).Error
+	return details, err
+}
+
+func (pdm *ProductDetailModel) Create(ctx context.Context, payload *structs.ProductDetailRequest) (structs.ProductDetail, error) {
+	db := pdm.db.WithContext(ctx)
+	detail := structs.ProductDetail{
+		ProductId:   payload.ProductId,
+		Type:        payload.Type,
+		Description: payload.Description,
+		PriceDelta:  payload.PriceDelta,
+		Stock:       payload.Stock,
+	}
+
+	if err := checkVariantPrice(db, payload.ProductId, payload.PriceDelta); err != nil {
+		return detail, err
+	}
+
+	err := db.Create(&detail).Error
+	return detail, err
+}
+
+func (pdm *ProductDetailModel) Update(ctx context.Context, payload *structs.ProductDetailRequest) (structs.ProductDetail, error) {
+	db := pdm.db.WithContext(ctx)
+	detail := structs.ProductDetail{}
+	if err := db.Where("product_id = ?", payload.ProductId).First(&detail, payload.ID).Error; err != nil {
+		return detail, err
+	}
+
+	if err := checkVariantPrice(db, payload.ProductId, payload.PriceDelta); err != nil {
+		return detail, err
+	}
+
+	detail.Type = payload.Type
+	detail.Description = payload.Description
+	detail.PriceDelta = payload.PriceDelta
+	detail.Stock = payload.Stock
+	err := db.Select("type", "description", "price_delta", "stock").Updates(&detail).Error
+	return detail, err
+}
+
+func (pdm *ProductDetailModel) Delete(ctx context.Context, productId, id uuid.UUID) error {
+	res := pdm.db.WithContext(ctx).Where("product_id = ?", productId).Delete(&structs.ProductDetail{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// checkVariantPrice refuses a price_delta that would sell the variant below zero
+func checkVariantPrice(db *gorm.DB, productId uuid.UUID, priceDelta float64) error {
+	product := structs.Product{}
+	if err := db.Select("id", "price").First(&product, productId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	if product.Price+priceDelta < 0 {
+		return ErrNegativePrice
+	}
+	return nil
+}
+
+// DecrementStock atomically takes quantity out of a variant's stock inside the caller's transaction
+func (pdm *ProductDetailModel) DecrementStock(tx *gorm.DB, id uuid.UUID, quantity int) error {
+	res := tx.Model(&structs.ProductDetail{}).
+		Where("id = ? AND stock >= ?", id, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// IncrementStock gives quantity back to a variant's stock inside the caller's transaction, a variant
+// in the trash gets it too so it is there when the variant is restored
+func (pdm *ProductDetailModel) IncrementStock(tx *gorm.DB, id uuid.UUID, quantity int) error {
+	return tx.Unscoped().Model(&structs.ProductDetail{}).
+		Where("id = ?", id).
+		Update("stock", gorm.Expr("stock + ?", quantity)).Error
+}
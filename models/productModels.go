@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCategoryNotFound = apperrors.New(http.StatusBadRequest, "category_not_found", "product category not found")
+
+var productImage = assetField{Table: structs.Product{}.TableName(), Field: "image", Category: structs.ProductImages}
+
+type ProductModel struct {
+	db *gorm.DB
+}
+
+func NewProductModel(db *gorm.DB) *ProductModel {
+	return &ProductModel{
+		db: db,
+	}
+}
+
+func (pm *ProductModel) GetAll(page *structs.Pagination, filter structs.ProductFilter, list structs.ListQuery, fields structs.FieldSet) ([]structs.Product, error) {
+	products := []structs.Product{}
+	query := pm.db.Model(&structs.Product{})
+	if filter.Search != "" {
+		keyword := "%" + filter.Search + "%"
+		query = query.Where("name LIKE ? OR sku LIKE ?", keyword, keyword)
+	}
+	if filter.ProductCategoryId != nil {
+		query = query.Where("product_category_id = ?", *filter.ProductCategoryId)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	query = helpers.ApplyFilters(query, list)
+
+	if err := helpers.Paginate(helpers.SelectFields(query, structs.ProductQuery, fields), page, list, &products, fields.Preloads...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+func (pm *ProductModel) GetById(id uuid.UUID, fields structs.FieldSet) (structs.Product, error) {
+	product := structs.Product{}
+	err := helpers.Preload(helpers.SelectFields(pm.db, structs.ProductQuery, fields), fields.Preloads...).First(&product, id).Error
+	return product, err
+}
+
+func (pm *ProductModel) Create(ctx context.Context, payload *structs.ProductRequest) (structs.Product, error) {
+	product := structs.Product{
+		ProductCategoryId: payload.ProductCategoryId,
+		Name:              payload.Name,
+		SKU:               payload.SKU,
+		Description:       payload.Description,
+		Price:             payload.Price,
+		IsActive:          payload.IsActive == nil || *payload.IsActive,
+	}
+	err := withAsset(pm.db.WithContext(ctx), productImage, payload.ImageAssetId, func(tx *gorm.DB, image string) (uuid.UUID, error) {
+		if err := lockCategory(tx, payload.ProductCategoryId); err != nil {
+			return product.ID, err
+		}
+		product.Image = image
+		err := tx.Create(&product).Error
+		return product.ID, err
+	})
+	return product, err
+}
+
+func (pm *ProductModel) Update(ctx context.Context, payload *structs.ProductRequest) (structs.Product, error) {
+	db := pm.db.WithContext(ctx)
+	product := structs.Product{}
+	if err := db.First(&product, payload.ID).Error; err != nil {
+		return product, err
+	}
+
+	product.ProductCategoryId = payload.ProductCategoryId
+	product.Name = payload.Name
+	product.SKU = payload.SKU
+	product.Description = payload.Description
+	product.Price = payload.Price
+	columns := []string{"product_category_id", "name", "sku", "description", "price"}
+	if payload.IsActive != nil {
+		product.IsActive = *payload.IsActive
+		columns = append(columns, "is_active")
+	}
+
+	err := withAsset(db, productImage, payload.ImageAssetId, func(tx *gorm.DB, image string) (uuid.UUID, error) {
+		if err := lockCategory(tx, payload.ProductCategoryId); err != nil {
+			return product.ID, err
+		}
+		var below int64
+		if err := tx.Model(&structs.ProductDetail{}).Where("product_id = ? AND price_delta < ?", product.ID, -product.Price).Count(&below).Error; err != nil {
+			return product.ID, err
+		}
+		if below > 0 {
+			return product.ID, ErrNegativePrice
+		}
+		if image != "" {
+			product.Image = image
+			columns = append(columns, "image")
+		}
+		return product.ID, tx.Select(columns).Updates(&product).Error
+	})
+	return product, err
+}
+
+func (pm *ProductModel) Delete(ctx context.Context, id uuid.UUID) error {
+	return softDelete(pm.db.WithContext(ctx), &structs.Product{}, id)
+}
+
+// lockCategory checks the category exists and holds a shared lock on it for the rest of tx, so
+// ProductCategoryModel.Delete can not remove it before the product is written
+func lockCategory(tx *gorm.DB, id uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&structs.ProductCategory{}).Clauses(clause.Locking{Strength: "SHARE"}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"simple-crud-rnd/structs"
+
+	"gorm.io/gorm"
+)
+
+// periodFormats maps the allowed group_by values to a MySQL DATE_FORMAT pattern
+var periodFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%x-W%v",
+	"month": "%Y-%m",
+}
+
+type ReportModel struct {
+	db *gorm.DB
+}
+
+func NewReportModel(db *gorm.DB) *ReportModel {
+	return &ReportModel{
+		db: db,
+	}
+}
+
+func (rm *ReportModel) SalesSummary(filter structs.ReportFilter) ([]structs.SalesSummary, error) {
+	summaries := []structs.SalesSummary{}
+	format, ok := periodFormats[filter.GroupBy]
+	if !ok {
+		format = periodFormats["day"]
+	}
+
+	err := rm.db.Model(&structs.Sale{}).
+		Select("DATE_FORMAT(date, ?) AS period, COUNT(*) AS total_transactions, "+
+			"SUM(subtotal) AS subtotal, SUM(discount) AS discount, SUM(tax) AS tax, SUM(grand_total) AS grand_total", format).
+		Where("date >= ? AND date < ?", filter.StartDate, filter.EndDate.AddDate(0, 0, 1)).
+		Group("period").
+		Order("period").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+func (rm *ReportModel) TopProducts(filter structs.ReportFilter) ([]structs.TopProduct, error) {
+	products := []structs.TopProduct{}
+	order := "quantity DESC"
+	if filter.OrderBy == "revenue" {
+		order = "revenue DESC"
+	}
+
+	err := rm.db.Table("t_sales_detail AS d").
+		Select("d.product_id, p.name, p.sku, SUM(d.quantity) AS quantity, SUM(d.subtotal) AS revenue").
+		Joins("JOIN t_sales AS s ON s.id = d.sale_id AND s.deleted_at IS NULL").
+		Joins("JOIN m_product AS p ON p.id = d.product_id").
+		Where("s.date >= ? AND s.date < ?", filter.StartDate, filter.EndDate.AddDate(0, 0, 1)).
+		Group("d.product_id, p.name, p.sku").
+		Order(order).
+		Limit(filter.Limit).
+		Scan(&products).Error
+	return products, err
+}
+
+func (rm *ReportModel) CustomerRevenue(filter structs.ReportFilter) ([]structs.CustomerRevenue, error) {
+	customers := []structs.CustomerRevenue{}
+
+	err := rm.db.Table("t_sales AS s").
+		Select("s.customer_id, c.name, COUNT(*) AS total_transactions, SUM(s.grand_total) AS revenue").
+		Joins("JOIN m_customer AS c ON c.id = s.customer_id").
+		Where("s.deleted_at IS NULL AND s.date >= ? AND s.date < ?", filter.StartDate, filter.EndDate.AddDate(0, 0, 1)).
+		Group("s.customer_id, c.name").
+		Order("revenue DESC").
+		Limit(filter.Limit).
+		Scan(&customers).Error
+	return customers, err
+}
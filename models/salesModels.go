@@ -0,0 +1,234 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCustomerNotFound = apperrors.New(http.StatusBadRequest, "customer_not_found", "customer not found")
+	ErrProductInactive  = apperrors.New(http.StatusBadRequest, "product_inactive", "product is not active")
+	ErrVariantNotFound  = apperrors.New(http.StatusBadRequest, "product_detail_not_found", "product detail not found for this product")
+	ErrInvalidDiscount  = apperrors.New(http.StatusBadRequest, "invalid_discount", "discount can not exceed the subtotal")
+)
+
+type SalesModel struct {
+	db                 *gorm.DB
+	productDetailModel *ProductDetailModel
+}
+
+func NewSalesModel(db *gorm.DB, productDetailModel *ProductDetailModel) *SalesModel {
+	return &SalesModel{
+		db:                 db,
+		productDetailModel: productDetailModel,
+	}
+}
+
+func (sm *SalesModel) GetAll(page *structs.Pagination, filter structs.SaleFilter, list structs.ListQuery, fields structs.FieldSet) ([]structs.Sale, error) {
+	sales := []structs.Sale{}
+	query := sm.db.Model(&structs.Sale{})
+	if filter.Search != "" {
+		query = query.Where("invoice_number LIKE ?", "%"+filter.Search+"%")
+	}
+	if filter.CustomerId != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerId)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("date < ?", filter.EndDate.AddDate(0, 0, 1))
+	}
+	query = helpers.ApplyFilters(query, list)
+
+	if err := helpers.Paginate(helpers.SelectFields(query, structs.SaleQuery, fields), page, list, &sales, fields.Preloads...); err != nil {
+		return nil, err
+	}
+
+	return sales, nil
+}
+
+func (sm *SalesModel) GetById(id uuid.UUID, fields structs.FieldSet) (structs.Sale, error) {
+	sale := structs.Sale{}
+	err := helpers.Preload(helpers.SelectFields(sm.db, structs.SaleQuery, fields), fields.Preloads...).First(&sale, id).Error
+	return sale, err
+}
+
+// Create writes the header and every line in one transaction. Prices and totals are always
+// taken from the catalog, never from the request.
+func (sm *SalesModel) Create(ctx context.Context, payload *structs.SaleRequest, taxRate float64) (structs.Sale, error) {
+	sale := structs.Sale{
+		CustomerId: payload.CustomerId,
+		Date:       time.Now(),
+		TaxRate:    taxRate,
+	}
+	if payload.Date != nil {
+		sale.Date = *payload.Date
+	}
+
+	err := sm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&structs.Customer{}).Where("id = ?", payload.CustomerId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrCustomerNotFound
+		}
+
+		for _, line := range payload.Details {
+			detail, err := sm.priceLine(tx, line)
+			if err != nil {
+				return err
+			}
+			sale.Details = append(sale.Details, detail)
+		}
+
+		if err := saleTotals(&sale, payload.Discount); err != nil {
+			return err
+		}
+
+		invoiceNumber, err := nextInvoiceNumber(tx, sale.Date)
+		if err != nil {
+			return err
+		}
+		sale.InvoiceNumber = invoiceNumber
+
+		return tx.Create(&sale).Error
+	})
+	return sale, err
+}
+
+// Delete moves the sale to the trash and gives the stock of its lines back, TakeStock takes it
+// again when the sale is restored
+func (sm *SalesModel) Delete(ctx context.Context, id uuid.UUID) error {
+	return sm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := softDelete(tx, &structs.Sale{}, id); err != nil {
+			return err
+		}
+
+		details, err := saleStockLines(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, detail := range details {
+			if err := sm.productDetailModel.IncrementStock(tx, *detail.ProductDetailId, detail.Quantity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TakeStock takes the stock of the lines of a sale restored from the trash, it fails with
+// ErrInsufficientStock when a variant was sold out in the meantime
+func (sm *SalesModel) TakeStock(tx *gorm.DB, id uuid.UUID) error {
+	details, err := saleStockLines(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, detail := range details {
+		if err := sm.productDetailModel.DecrementStock(tx, *detail.ProductDetailId, detail.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saleStockLines are the lines of a sale that hold stock of a variant
+func saleStockLines(tx *gorm.DB, saleId uuid.UUID) ([]structs.SaleDetail, error) {
+	details := []structs.SaleDetail{}
+	err := tx.Where("sale_id = ? AND product_detail_id IS NOT NULL", saleId).Find(&details).Error
+	return details, err
+}
+
+func (sm *SalesModel) priceLine(tx *gorm.DB, line structs.SaleDetailRequest) (structs.SaleDetail, error) {
+	detail := structs.SaleDetail{
+		ProductId:       line.ProductId,
+		ProductDetailId: line.ProductDetailId,
+		Quantity:        line.Quantity,
+	}
+
+	product := structs.Product{}
+	if err := tx.First(&product, line.ProductId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return detail, ErrProductNotFound
+		}
+		return detail, err
+	}
+	if !product.IsActive {
+		return detail, ErrProductInactive
+	}
+	detail.Price = product.Price
+
+	if line.ProductDetailId != nil {
+		variant := structs.ProductDetail{}
+		if err := tx.Where("product_id = ?", product.ID).First(&variant, *line.ProductDetailId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return detail, ErrVariantNotFound
+			}
+			return detail, err
+		}
+		if err := sm.productDetailModel.DecrementStock(tx, variant.ID, line.Quantity); err != nil {
+			return detail, err
+		}
+		detail.Price += variant.PriceDelta
+	}
+
+	// Variants are checked when saved, this guards rows written before that check existed
+	detail.Price = roundMoney(detail.Price)
+	if detail.Price < 0 {
+		return detail, ErrNegativePrice
+	}
+	detail.Subtotal = roundMoney(detail.Price * float64(line.Quantity))
+	return detail, nil
+}
+
+// saleTotals sums the priced lines of the sale and applies the discount and its tax rate
+func saleTotals(sale *structs.Sale, discount float64) error {
+	sale.Subtotal = 0
+	for _, detail := range sale.Details {
+		sale.Subtotal += detail.Subtotal
+	}
+
+	sale.Subtotal = roundMoney(sale.Subtotal)
+	sale.Discount = roundMoney(discount)
+	if sale.Discount > sale.Subtotal {
+		return ErrInvalidDiscount
+	}
+	sale.Tax = roundMoney((sale.Subtotal - sale.Discount) * sale.TaxRate / 100)
+	sale.GrandTotal = roundMoney(sale.Subtotal - sale.Discount + sale.Tax)
+	return nil
+}
+
+// nextInvoiceNumber increments the monthly counter. The upserted row stays locked until the
+// transaction ends, so concurrent sales are serialized and never share a number.
+func nextInvoiceNumber(tx *gorm.DB, date time.Time) (string, error) {
+	sequence := structs.InvoiceSequence{Period: date.Format("2006/01"), LastNumber: 1}
+	if err := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"last_number": gorm.Expr("last_number + 1")}),
+	}).Create(&sequence).Error; err != nil {
+		return "", err
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("period = ?", sequence.Period).First(&sequence).Error; err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("INV/%s/%04d", sequence.Period, sequence.LastNumber), nil
+}
+
+func roundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"simple-crud-rnd/structs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, SkipDefaultTransaction: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.ConnPool = dryRunPool{}
+	db.Statement.ConnPool = dryRunPool{}
+	return db
+}
+
+// dryRunPool lets models open transactions on a dry run database, nothing is sent through it
+type dryRunPool struct{}
+
+func (dryRunPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return dryRunPool{}, nil
+}
+
+func (dryRunPool) Commit() error   { return nil }
+func (dryRunPool) Rollback() error { return nil }
+
+func (dryRunPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+
+func (dryRunPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+
+func (dryRunPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+
+func (dryRunPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+var errDryRun = errors.New("dry run database")
+
+// recordSQL collects the statements db builds, dry run does not send them anywhere
+func recordSQL(t *testing.T, db *gorm.DB) *[]string {
+	t.Helper()
+	statements := []string{}
+	record := func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}
+	for name, err := range map[string]error{
+		"create": db.Callback().Create().After("gorm:create").Register("test:record", record),
+		"query":  db.Callback().Query().After("gorm:query").Register("test:record", record),
+		"update": db.Callback().Update().After("gorm:update").Register("test:record", record),
+	} {
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	return &statements
+}
+
+func TestSaleTotals(t *testing.T) {
+	type totals struct {
+		Subtotal, Discount, Tax, GrandTotal float64
+	}
+	tests := []struct {
+		name      string
+		subtotals []float64
+		discount  float64
+		taxRate   float64
+		want      totals
+		err       error
+	}{
+		{
+			name:      "no discount nor tax",
+			subtotals: []float64{10000, 2500.5},
+			want:      totals{Subtotal: 12500.5, GrandTotal: 12500.5},
+		},
+		{
+			name:      "tax on the discounted subtotal",
+			subtotals: []float64{10000, 5000},
+			discount:  5000,
+			taxRate:   11,
+			want:      totals{Subtotal: 15000, Discount: 5000, Tax: 1100, GrandTotal: 11100},
+		},
+		{
+			name:      "rounded to cents",
+			subtotals: []float64{0.1, 0.2},
+			discount:  0.004,
+			taxRate:   12.5,
+			want:      totals{Subtotal: 0.3, Discount: 0, Tax: 0.04, GrandTotal: 0.34},
+		},
+		{
+			name:      "discount of the whole subtotal",
+			subtotals: []float64{100},
+			discount:  100,
+			taxRate:   11,
+			want:      totals{Subtotal: 100, Discount: 100, GrandTotal: 0},
+		},
+		{
+			name:      "discount over the subtotal",
+			subtotals: []float64{100},
+			discount:  100.01,
+			err:       ErrInvalidDiscount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A total sent by the client is overwritten
+			sale := structs.Sale{TaxRate: tt.taxRate, Subtotal: 1, Tax: 1, GrandTotal: 1}
+			for _, subtotal := range tt.subtotals {
+				sale.Details = append(sale.Details, structs.SaleDetail{Subtotal: subtotal})
+			}
+
+			err := saleTotals(&sale, tt.discount)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			got := totals{Subtotal: sale.Subtotal, Discount: sale.Discount, Tax: sale.Tax, GrandTotal: sale.GrandTotal}
+			if got != tt.want {
+				t.Errorf("totals = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextInvoiceNumber(t *testing.T) {
+	db := dryRunDB(t)
+	statements := recordSQL(t, db)
+
+	number, err := nextInvoiceNumber(db, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dry run reads nothing back, the counter is the one the upsert starts a month at
+	if number != "INV/2024/03/0001" {
+		t.Errorf("number = %q, want INV/2024/03/0001", number)
+	}
+
+	if len(*statements) != 2 {
+		t.Fatalf("statements = %q, want the upsert and the locking read", *statements)
+	}
+	upsert, read := (*statements)[0], (*statements)[1]
+	if !strings.Contains(upsert, "'2024/03'") || !strings.Contains(upsert, "ON DUPLICATE KEY UPDATE `last_number`=last_number + 1") {
+		t.Errorf("the counter of the month is not incremented by %s", upsert)
+	}
+	if !strings.Contains(read, "period = '2024/03'") || !strings.HasSuffix(read, "FOR UPDATE") {
+		t.Errorf("the counter of the month is not read locked by %s", read)
+	}
+}
+
+func TestIncrementStockReachesTrashedVariants(t *testing.T) {
+	db := dryRunDB(t)
+	statements := recordSQL(t, db)
+
+	if err := NewProductDetailModel(db).IncrementStock(db, uuid.New(), 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 1 {
+		t.Fatalf("statements = %q, want one update", *statements)
+	}
+	update := (*statements)[0]
+	if !strings.Contains(update, "`stock`=stock + 3") || strings.Contains(update, "deleted_at") {
+		t.Errorf("stock is not given back to every variant by %s", update)
+	}
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"context"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrashModel lists, restores and purges the soft deleted rows of the table of T, so every
+// module with a deleted_at column gets a trash without writing its own queries
+type TrashModel[T structs.Tabler] struct {
+	db        *gorm.DB
+	schema    structs.QuerySchema
+	onRestore []RestoreHook
+}
+
+// RestoreHook runs in the transaction restoring the row id, it takes back what the row gave up
+// when it was deleted, such as the stock of a sale
+type RestoreHook func(tx *gorm.DB, id uuid.UUID) error
+
+// NewTrashModel takes the schema of the trash, see structs.QuerySchema.Trashed
+func NewTrashModel[T structs.Tabler](db *gorm.DB, schema structs.QuerySchema, onRestore ...RestoreHook) *TrashModel[T] {
+	return &TrashModel[T]{db, schema, onRestore}
+}
+
+func (tm *TrashModel[T]) Schema() structs.QuerySchema {
+	return tm.schema
+}
+
+func (tm *TrashModel[T]) GetAll(page *structs.Pagination, list structs.ListQuery, fields structs.FieldSet) ([]T, error) {
+	rows := []T{}
+	query := helpers.ApplyFilters(tm.trashed(), list)
+
+	if err := helpers.Paginate(helpers.SelectFields(query, tm.schema, fields), page, list, &rows, fields.Preloads...); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Restore takes the row out of the trash. It fails with a duplicate entry when a live row took
+// one of its unique values in the meantime.
+func (tm *TrashModel[T]) Restore(ctx context.Context, id uuid.UUID) (T, error) {
+	var row T
+	err := tm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Model(&row).Where("deleted_at IS NOT NULL AND id = ?", id).UpdateColumns(map[string]interface{}{"deleted_at": nil, "deleted_by": nil})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		for _, hook := range tm.onRestore {
+			if err := hook(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return row, err
+	}
+
+	err = helpers.SelectFields(tm.db.WithContext(ctx), tm.schema, structs.FieldSet{}).First(&row, id).Error
+	return row, err
+}
+
+// Purge erases a row that is already in the trash, together with the rows it owns such as sale
+// lines, and releases the assets it references. Assets stay referenced while a row is in the trash,
+// so a restored row keeps its files. Rows still referenced from elsewhere fail with a still
+// referenced error.
+func (tm *TrashModel[T]) Purge(ctx context.Context, id uuid.UUID) error {
+	return tm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var row T
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&row, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Select(clause.Associations).Delete(&row).Error; err != nil {
+			return err
+		}
+		return releaseOwner(tx, row.TableName(), id)
+	})
+}
+
+func (tm *TrashModel[T]) trashed() *gorm.DB {
+	var row T
+	return tm.db.Unscoped().Model(&row).Where("deleted_at IS NOT NULL")
+}
+
+// softDelete moves a row to the trash, helpers.AuditColumns records who deleted it
+func softDelete(db *gorm.DB, model structs.Tabler, id uuid.UUID) error {
+	res := db.Delete(model, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -1,16 +1,21 @@
 package models
 
 import (
-	"errors"
+	"context"
+	"net/http"
+	"simple-crud-rnd/apperrors"
 	"simple-crud-rnd/helpers"
 	"simple-crud-rnd/structs"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
+var ErrInvalidPassword = apperrors.New(http.StatusBadRequest, "invalid_password", "old password is incorrect")
+
+var userPhoto = assetField{Table: structs.User{}.TableName(), Field: "photo", Category: structs.ProfilePhotos}
+
 type UserModel struct {
 	db *gorm.DB
 }
@@ -21,29 +26,32 @@ func NewUserModel(db *gorm.DB) *UserModel {
 	}
 }
 
-func (um *UserModel) GetAll(limit, offset int) ([]structs.User, int64, error) {
+func (um *UserModel) GetAll(page *structs.Pagination, list structs.ListQuery, fields structs.FieldSet) ([]structs.User, error) {
 	users := []structs.User{}
-	if err := um.db.Select("id", "name", "email", "phone_number", "photo", "user_roles_id", "updated_security", "created_at", "updated_at").
-		Find(&users).Limit(limit).Offset(offset).Error; err != nil {
-		return nil, 0, err
-	}
+	query := helpers.SelectFields(helpers.ApplyFilters(um.db.Model(&structs.User{}), list), structs.UserQuery, fields)
 
-	var count int64
-	if err := um.db.Table("m_user").Where("deleted_at IS NULL").Count(&count).Error; err != nil {
-		return nil, 0, err
+	if err := helpers.Paginate(query, page, list, &users, fields.Preloads...); err != nil {
+		return nil, err
 	}
 
-	return users, count, nil
+	return users, nil
 }
 
-func (um *UserModel) GetById(id uuid.UUID) (structs.User, error) {
+// GetById never selects the password, the zero FieldSet selects every other column
+func (um *UserModel) GetById(id uuid.UUID, fields structs.FieldSet) (structs.User, error) {
 	user := structs.User{}
-	err := um.db.Select("id", "name", "email", "phone_number", "photo", "user_roles_id", "updated_security", "created_at", "updated_at").
+	err := helpers.Preload(helpers.SelectFields(um.db, structs.UserQuery, fields), fields.Preloads...).
 		Where("deleted_at IS NULL").First(&user, id).Error
 	return user, err
 }
 
-func (um *UserModel) Create(payload *structs.UserRequest) (structs.User, error) {
+func (um *UserModel) GetByEmail(email string) (structs.User, error) {
+	user := structs.User{}
+	err := um.db.Where("email = ?", email).First(&user).Error
+	return user, err
+}
+
+func (um *UserModel) Create(ctx context.Context, payload *structs.UserRequest) (structs.User, error) {
 	var user structs.User
 	hashedPassword, pwErr := helpers.PasswordHash(payload.Password)
 	if pwErr != nil {
@@ -53,48 +61,126 @@ func (um *UserModel) Create(payload *structs.UserRequest) (structs.User, error)
 		Name:            payload.Name,
 		Email:           payload.Email,
 		PhoneNumber:     payload.PhoneNumber,
+		Language:        payload.Language,
 		Password:        hashedPassword,
-		Photo:           payload.Photo,
 		UserRolesId:     payload.UserRolesId,
 		UpdatedSecurity: time.Now(),
 	}
 
-	res := um.db.Create(&user).Clauses(clause.Returning{
-		Columns: []clause.Column{
-			{Name: "id"},
-			{Name: "name"},
-			{Name: "email"},
-			{Name: "phone_number"},
-			{Name: "photo"},
-			{Name: "updated_security"},
-		},
+	err := withAsset(um.db.WithContext(ctx), userPhoto, payload.PhotoAssetId, func(tx *gorm.DB, photo string) (uuid.UUID, error) {
+		user.Photo = photo
+		err := tx.Create(&user).Error
+		return user.ID, err
 	})
+	return user, err
+}
 
-	if res.Error != nil {
-		return user, res.Error
+// Update changes the profile of the user, a password given replaces the old one and signs the
+// user out everywhere
+func (um *UserModel) Update(ctx context.Context, payload *structs.UserUpdateRequest) (structs.User, error) {
+	db := um.db.WithContext(ctx)
+	user := structs.User{}
+	if err := helpers.SelectFields(db, structs.UserQuery, structs.FieldSet{}).Where("deleted_at IS NULL").First(&user, payload.ID).Error; err != nil {
+		return user, err
+	}
+
+	user.Name = payload.Name
+	user.Email = payload.Email
+	user.PhoneNumber = payload.PhoneNumber
+	user.Language = payload.Language
+	columns := []string{"name", "email", "phone_number", "language"}
+	if payload.Password != "" {
+		hashedPassword, err := helpers.PasswordHash(payload.Password)
+		if err != nil {
+			return user, err
+		}
+		user.Password = hashedPassword
+		user.UpdatedSecurity = time.Now()
+		columns = append(columns, "password", "updated_security")
 	}
 
-	return user, nil
+	err := withAsset(db, userPhoto, payload.PhotoAssetId, func(tx *gorm.DB, photo string) (uuid.UUID, error) {
+		if photo != "" {
+			user.Photo = photo
+			columns = append(columns, "photo")
+		}
+		if err := tx.Select(columns).Updates(&user).Error; err != nil {
+			return user.ID, err
+		}
+		if payload.Password != "" {
+			return user.ID, tx.Where("user_id = ?", user.ID).Delete(&structs.RefreshToken{}).Error
+		}
+		return user.ID, nil
+	})
+	return user, err
+}
+
+// BumpSecurity moves updated_security forward, invalidating every token issued before now
+func (um *UserModel) BumpSecurity(ctx context.Context, id uuid.UUID) error {
+	return um.updateSecurity(ctx, id, map[string]interface{}{})
 }
 
-func (um *UserModel) Update(payload structs.User) (structs.User, error) {
-	user := structs.User{ID: payload.ID}
-	hashedPassword, pwErr := helpers.PasswordHash(payload.Password)
-	if pwErr != nil {
-		return user, pwErr
+func (um *UserModel) ChangePassword(ctx context.Context, id uuid.UUID, oldPassword, newPassword string) error {
+	user := structs.User{}
+	if err := um.db.WithContext(ctx).Select("id", "password").First(&user, id).Error; err != nil {
+		return err
+	}
+	if !helpers.PasswordVerify(user.Password, oldPassword) {
+		return ErrInvalidPassword
+	}
+
+	hashedPassword, err := helpers.PasswordHash(newPassword)
+	if err != nil {
+		return err
+	}
+	return um.updateSecurity(ctx, id, map[string]interface{}{"password": hashedPassword})
+}
+
+func (um *UserModel) SetLanguage(ctx context.Context, id uuid.UUID, language string) error {
+	return um.db.WithContext(ctx).Model(&structs.User{}).Where("id = ?", id).Update("language", language).Error
+}
+
+// SetRole moves the user to another role, permissions are loaded per request so it applies at once
+func (um *UserModel) SetRole(ctx context.Context, id, roleId uuid.UUID) error {
+	res := um.db.WithContext(ctx).Model(&structs.User{}).Where("id = ?", id).Update("user_roles_id", roleId.String())
+	if res.Error != nil {
+		return res.Error
 	}
-	payload.Password = hashedPassword
-	res := um.db.Model(&user).Clauses(clause.Returning{}).Updates(&payload)
 	if res.RowsAffected == 0 {
-		return user, errors.New("no rows updated")
+		return gorm.ErrRecordNotFound
 	}
-	return user, nil
+	return nil
+}
+
+func (um *UserModel) Lock(ctx context.Context, id uuid.UUID) error {
+	return um.updateSecurity(ctx, id, map[string]interface{}{"locked_at": time.Now()})
 }
 
-func (um *UserModel) Delete(id uuid.UUID) error {
-	res := um.db.Delete(&structs.User{}, id)
+func (um *UserModel) Unlock(ctx context.Context, id uuid.UUID) error {
+	res := um.db.WithContext(ctx).Model(&structs.User{}).Where("id = ?", id).Update("locked_at", nil)
+	if res.Error != nil {
+		return res.Error
+	}
 	if res.RowsAffected == 0 {
-		return errors.New("no rows deleted")
+		return gorm.ErrRecordNotFound
 	}
 	return nil
 }
+
+func (um *UserModel) updateSecurity(ctx context.Context, id uuid.UUID, columns map[string]interface{}) error {
+	columns["updated_security"] = time.Now()
+	return um.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&structs.User{}).Where("id = ?", id).Updates(columns)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("user_id = ?", id).Delete(&structs.RefreshToken{}).Error
+	})
+}
+
+func (um *UserModel) Delete(ctx context.Context, id uuid.UUID) error {
+	return softDelete(um.db.WithContext(ctx), &structs.User{}, id)
+}
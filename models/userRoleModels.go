@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"net/http"
+	"simple-crud-rnd/apperrors"
+	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/structs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRoleInUse     = apperrors.New(http.StatusConflict, "role_in_use", "role is still assigned to users")
+	ErrRoleProtected = apperrors.New(http.StatusForbidden, "role_protected", "The Super Admin role can not be changed")
+)
+
+type UserRoleModel struct {
+	db *gorm.DB
+}
+
+func NewUserRoleModel(db *gorm.DB) *UserRoleModel {
+	return &UserRoleModel{
+		db: db,
+	}
+}
+
+// byName is the fixed order of lists that can not be sorted by the client
+var byName = structs.ListQuery{Sorts: []structs.QuerySort{{Column: "name", Type: structs.QueryText}}}
+
+func (rm *UserRoleModel) GetAll(page *structs.Pagination, search string) ([]structs.UserRole, error) {
+	roles := []structs.UserRole{}
+	query := rm.db.Model(&structs.UserRole{})
+	if search != "" {
+		query = query.Where("name LIKE ?", "%"+search+"%")
+	}
+
+	if err := helpers.Paginate(query, page, byName, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (rm *UserRoleModel) GetById(id uuid.UUID) (structs.UserRole, error) {
+	role := structs.UserRole{}
+	err := rm.db.First(&role, id).Error
+	return role, err
+}
+
+func (rm *UserRoleModel) GetByName(name string) (structs.UserRole, error) {
+	role := structs.UserRole{}
+	err := rm.db.Where("name = ?", name).First(&role).Error
+	return role, err
+}
+
+func (rm *UserRoleModel) Create(ctx context.Context, payload *structs.UserRoleRequest) (structs.UserRole, error) {
+	role := structs.UserRole{
+		Name:        payload.Name,
+		Permissions: payload.Permissions,
+	}
+	err := rm.db.WithContext(ctx).Create(&role).Error
+	return role, err
+}
+
+// Update refuses the super admin role, see Delete
+func (rm *UserRoleModel) Update(ctx context.Context, payload *structs.UserRoleRequest) (structs.UserRole, error) {
+	db := rm.db.WithContext(ctx)
+	role := structs.UserRole{}
+	if err := db.First(&role, payload.ID).Error; err != nil {
+		return role, err
+	}
+	if role.Name == structs.SuperAdminRole {
+		return role, ErrRoleProtected
+	}
+
+	role.Name = payload.Name
+	role.Permissions = payload.Permissions
+	err := db.Select("name", "permissions").Updates(&role).Error
+	return role, err
+}
+
+// Delete refuses the super admin role, it is the only one holding structs.PermissionAll and the
+// API can not grant that permission to another
+func (rm *UserRoleModel) Delete(ctx context.Context, id uuid.UUID) error {
+	return rm.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role := structs.UserRole{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&role, id).Error; err != nil {
+			return err
+		}
+		if role.Name == structs.SuperAdminRole {
+			return ErrRoleProtected
+		}
+
+		// Users in the trash count too, restoring one must not leave it without a role
+		var count int64
+		if err := tx.Unscoped().Model(&structs.User{}).Where("user_roles_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleInUse
+		}
+
+		return tx.Delete(&role).Error
+	})
+}
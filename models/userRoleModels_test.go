@@ -0,0 +1,39 @@
+package models
+
+import (
+	"context"
+	"simple-crud-rnd/structs"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestUserRoleDeleteCountsTrashedUsers(t *testing.T) {
+	db := dryRunDB(t)
+	statements := recordSQL(t, db)
+	id := uuid.New()
+	err := db.Callback().Query().After("gorm:query").Register("test:role", func(tx *gorm.DB) {
+		if role, ok := tx.Statement.Dest.(*structs.UserRole); ok {
+			*role = structs.UserRole{ID: id, Name: "Cashier"}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewUserRoleModel(db).Delete(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, statement := range *statements {
+		if strings.HasPrefix(statement, "SELECT count(*) FROM `m_user`") {
+			if strings.Contains(statement, "deleted_at") {
+				t.Errorf("users in the trash are not counted by %s", statement)
+			}
+			return
+		}
+	}
+	t.Errorf("users are not counted by %q", *statements)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"simple-crud-rnd/config"
 	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/middlewares"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
@@ -23,6 +24,23 @@ type HTTPServer struct {
 func NewHTTPServer(cfg *config.Config, db *gorm.DB) HTTPServer {
 	e := echo.New()
 	e.Validator = helpers.NewValidator(validator.New())
+	e.HTTPErrorHandler = helpers.HTTPErrorHandler
+	// The client IP is the peer's address, X-Forwarded-For is only believed from the configured proxies
+	e.IPExtractor = echo.ExtractIPDirect()
+	if len(cfg.HTTP.TrustedProxies) > 0 {
+		options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+		for _, proxy := range cfg.HTTP.TrustedProxies {
+			options = append(options, echo.TrustIPRange(proxy))
+		}
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(options...)
+	}
+
+	translator, err := helpers.NewTranslator(cfg.Language.Default)
+	if err != nil {
+		log.Fatal("Failed to load translations: ", err)
+	}
+	e.Use(middlewares.Localize(translator))
+	e.Use(middlewares.RequestID(), middlewares.RequestInfo)
 
 	return HTTPServer{
 		db:         db,
@@ -52,14 +70,18 @@ func testPort(port int) (int, error) {
 func (s *HTTPServer) RunHTTPServer() {
 	api := InitVersionOne(s.httpServer, s.db, s.cfg)
 
-	s.httpServer.Static(api.cfg.HTTP.AssetEndpoint, api.cfg.AssetStorage.Path)
+	if api.cfg.AssetStorage.Driver == "local" {
+		assets := echo.StaticDirectoryHandler(echo.MustSubFS(s.httpServer.Filesystem, api.cfg.AssetStorage.Path), false)
+		s.httpServer.GET(api.cfg.HTTP.AssetEndpoint+"*", assets, middlewares.AssetHeaders)
+	}
 	api.UserAndAuth()
-	// api.Customer()
-	// api.ProductCategory()
-	// api.Product()
-	// api.Sales()
-	// api.Report()
-	// api.Assets()
+	api.Customer()
+	api.ProductCategory()
+	api.Product()
+	api.Sales()
+	api.Report()
+	api.Assets()
+	api.AuditLog()
 
 	openPort, err := testPort(s.cfg.HTTP.Port)
 	if err != nil {
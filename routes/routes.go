@@ -6,50 +6,248 @@ import (
 	"simple-crud-rnd/config"
 	"simple-crud-rnd/controllers"
 	"simple-crud-rnd/helpers"
+	"simple-crud-rnd/middlewares"
 	"simple-crud-rnd/models"
+	"simple-crud-rnd/structs"
 
+	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"gorm.io/gorm"
 )
 
+// multipartOverhead is allowed on top of the file size for the boundaries, headers and other fields
+const multipartOverhead = 64 << 10
+
 type APIVersionOne struct {
-	e          *echo.Echo
-	db         *gorm.DB
-	cfg        *config.Config
-	api        *echo.Group
-	assetsPath string
+	e              *echo.Echo
+	db             *gorm.DB
+	cfg            *config.Config
+	api            *echo.Group
+	authMiddleware *middlewares.AuthMiddleware
+	storage        helpers.Storage
+	privateStorage helpers.Storage
 }
 
 func InitVersionOne(e *echo.Echo, db *gorm.DB, cfg *config.Config) *APIVersionOne {
+	storage, err := config.NewStorage(cfg)
+	if err != nil {
+		log.Fatal("Failed to initiate the asset storage:", err)
+	}
+	privateStorage, err := config.NewPrivateStorage(cfg)
+	if err != nil {
+		log.Fatal("Failed to initiate the private asset storage:", err)
+	}
+
 	return &APIVersionOne{
 		e,
 		db,
 		cfg,
 		e.Group("/api/v1"),
-		fmt.Sprintf("%s/%s", cfg.HTTP.Domain, cfg.HTTP.AssetEndpoint),
+		middlewares.NewAuthMiddleware(models.NewUserModel(db)),
+		storage,
+		privateStorage,
+	}
+}
+
+// authenticated returns a group that requires a valid, unrevoked access token
+func (av *APIVersionOne) authenticated(prefix string, m ...echo.MiddlewareFunc) *echo.Group {
+	m = append([]echo.MiddlewareFunc{echojwt.WithConfig(av.cfg.JWT.Config), av.authMiddleware.ValidateSecurity}, m...)
+	return av.api.Group(prefix, m...)
+}
+
+// imageHelper stores private categories, such as profile photos, where only signed URLs reach them
+func (av *APIVersionOne) imageHelper(category string) *helpers.ImageHelper {
+	storage := av.storage
+	if av.cfg.AssetStorage.IsPrivate(category) {
+		storage = av.privateStorage
+	}
+
+	return helpers.NewImageHelper(storage, category, helpers.ImageOptions{
+		MaxSize:   av.cfg.AssetStorage.ImageMaxSize,
+		MaxWidth:  av.cfg.AssetStorage.ImageMaxWidth,
+		MaxHeight: av.cfg.AssetStorage.ImageMaxHeight,
+		Variants:  av.cfg.AssetStorage.ImageVariants,
+	})
+}
+
+func (av *APIVersionOne) fileHelper(category string) *helpers.FileHelper {
+	storage := av.storage
+	if av.cfg.AssetStorage.IsPrivate(category) {
+		storage = av.privateStorage
 	}
+
+	return helpers.NewFileHelper(storage, category, av.cfg.AssetStorage.FileMaxSize)
+}
+
+func (av *APIVersionOne) can(permission string) echo.MiddlewareFunc {
+	return av.authMiddleware.RequirePermission(permission)
+}
+
+// trash adds GET /trash, POST /:id/restore and DELETE /:id/purge to the group of a module. Viewing
+// and restoring need the delete permission of the module, purging its own purge permission.
+func trash[T structs.Tabler](av *APIVersionOne, group *echo.Group, schema structs.QuerySchema, module string, onRestore ...models.RestoreHook) {
+	trashController := controllers.NewTrashController(models.NewTrashModel[T](av.db, schema.Trashed(), onRestore...))
+
+	group.GET("/trash", trashController.Index, av.can(module+".delete"))
+	group.POST("/:id/restore", trashController.Restore, av.can(module+".delete"))
+	group.DELETE("/:id/purge", trashController.Purge, av.can(module+".purge"))
 }
 
 func (av *APIVersionOne) UserAndAuth() {
 	userModel := models.NewUserModel(av.db)
-	imageHelper, err := helpers.NewImageHelper(av.cfg.AssetStorage.Path, "profile_photos")
-	if err != nil {
-		log.Fatal("Failed to initiate an image helper:", err)
-	}
+	imageHelper := av.imageHelper(structs.ProfilePhotos)
 
-	userController := controllers.NewUserController(av.db, userModel, av.cfg, imageHelper, av.assetsPath)
-	// authController := controllers.NewAuthController(av.db, userModel, av.cfg)
+	roleModel := models.NewUserRoleModel(av.db)
+	userController := controllers.NewUserController(av.db, userModel, roleModel, models.NewAssetModel(av.db), av.cfg, imageHelper)
+	authController := controllers.NewAuthController(av.db, userModel, roleModel, models.NewAuthModel(av.db), av.cfg)
+	roleController := controllers.NewUserRoleController(av.db, roleModel, av.cfg)
 
 	auth := av.api.Group("/auth")
-	// auth.POST("/login", authController.Login)
-	auth.POST("/signup", userController.Create)
+	auth.POST("/login", authController.Login)
+	auth.POST("/refresh", authController.Refresh)
+	auth.POST("/logout", authController.Logout)
+	auth.POST("/signup", authController.SignUp)
+
+	authenticatedAuth := av.authenticated("/auth")
+	authenticatedAuth.POST("/logout-all", authController.LogoutAll)
+
+	user := av.authenticated("/users")
+
+	user.GET("", userController.Index, av.can("users.view"))
+	user.POST("", userController.Create, av.can("users.create"))
+	user.GET("/:id", userController.GetById, av.can("users.view"))
+	user.PUT("", userController.Update, av.can("users.update"))
+	user.PUT("/password", userController.ChangePassword)
+	user.PUT("/language", userController.ChangeLanguage)
+	user.PUT("/:id/role", userController.SetRole, av.can("users.assign-role"))
+	user.POST("/:id/lock", userController.Lock, av.can("users.lock"))
+	user.DELETE("/:id/lock", userController.Unlock, av.can("users.lock"))
+	user.DELETE("/:id", userController.Delete, av.can("users.delete"))
+	trash[structs.User](av, user, structs.UserQuery, "users")
+
+	role := av.authenticated("/roles")
+
+	role.GET("", roleController.Index, av.can("roles.view"))
+	role.GET("/permissions", roleController.Permissions, av.can("roles.view"))
+	role.POST("", roleController.Create, av.can("roles.create"))
+	role.GET("/:id", roleController.GetById, av.can("roles.view"))
+	role.PUT("", roleController.Update, av.can("roles.update"))
+	role.DELETE("/:id", roleController.Delete, av.can("roles.delete"))
+}
+
+func (av *APIVersionOne) Customer() {
+	customerModel := models.NewCustomerModel(av.db)
+	customerController := controllers.NewCustomerController(av.db, customerModel, av.cfg, av.imageHelper(structs.CustomerPhotos))
+
+	customer := av.authenticated("/customers")
+
+	customer.GET("", customerController.Index, av.can("customers.view"))
+	customer.POST("", customerController.Create, av.can("customers.create"))
+	customer.GET("/:id", customerController.GetById, av.can("customers.view"))
+	customer.PUT("", customerController.Update, av.can("customers.update"))
+	customer.DELETE("/:id", customerController.Delete, av.can("customers.delete"))
+	trash[structs.Customer](av, customer, structs.CustomerQuery, "customers")
+}
+
+func (av *APIVersionOne) ProductCategory() {
+	productCategoryModel := models.NewProductCategoryModel(av.db)
+	productCategoryController := controllers.NewProductCategoryController(av.db, productCategoryModel, av.cfg)
+
+	productCategory := av.authenticated("/product-categories")
+
+	productCategory.GET("", productCategoryController.Index, av.can("product_categories.view"))
+	productCategory.POST("", productCategoryController.Create, av.can("product_categories.create"))
+	productCategory.GET("/:id", productCategoryController.GetById, av.can("product_categories.view"))
+	productCategory.PUT("", productCategoryController.Update, av.can("product_categories.update"))
+	productCategory.DELETE("/:id", productCategoryController.Delete, av.can("product_categories.delete"))
+}
+
+func (av *APIVersionOne) Product() {
+	productModel := models.NewProductModel(av.db)
+	productController := controllers.NewProductController(av.db, productModel, av.cfg, av.imageHelper(structs.ProductImages))
+	productDetailController := controllers.NewProductDetailController(av.db, models.NewProductDetailModel(av.db), av.cfg)
+
+	product := av.authenticated("/products")
+
+	product.GET("", productController.Index, av.can("products.view"))
+	product.POST("", productController.Create, av.can("products.create"))
+	product.GET("/:id", productController.GetById, av.can("products.view"))
+	product.PUT("", productController.Update, av.can("products.update"))
+	product.DELETE("/:id", productController.Delete, av.can("products.delete"))
+	trash[structs.Product](av, product, structs.ProductQuery, "products")
+
+	product.GET("/:id/details", productDetailController.Index, av.can("products.view"))
+	product.POST("/:id/details", productDetailController.Create, av.can("products.update"))
+	product.PUT("/:id/details", productDetailController.Update, av.can("products.update"))
+	product.DELETE("/:id/details/:detailId", productDetailController.Delete, av.can("products.update"))
+}
+
+func (av *APIVersionOne) Sales() {
+	salesModel := models.NewSalesModel(av.db, models.NewProductDetailModel(av.db))
+	salesController := controllers.NewSalesController(av.db, salesModel, av.cfg)
+
+	sales := av.authenticated("/sales")
+
+	sales.GET("", salesController.Index, av.can("sales.view"))
+	sales.POST("", salesController.Create, av.can("sales.create"))
+	sales.GET("/:id", salesController.GetById, av.can("sales.view"))
+	sales.DELETE("/:id", salesController.Delete, av.can("sales.delete"))
+	trash[structs.Sale](av, sales, structs.SaleQuery, "sales", salesModel.TakeStock)
+}
+
+func (av *APIVersionOne) Report() {
+	reportController := controllers.NewReportController(av.db, models.NewReportModel(av.db), av.cfg)
+
+	report := av.authenticated("/reports", av.can("reports.view"))
+
+	report.GET("/sales", reportController.Sales)
+	report.GET("/sales/download", reportController.SalesDownload)
+	report.GET("/top-products", reportController.TopProducts)
+	report.GET("/top-products/download", reportController.TopProductsDownload)
+	report.GET("/customers", reportController.Customers)
+	report.GET("/customers/download", reportController.CustomersDownload)
+}
+
+func (av *APIVersionOne) Assets() {
+	imageHelpers := []*helpers.ImageHelper{}
+	for _, category := range []string{structs.ProfilePhotos, structs.CustomerPhotos, structs.ProductImages} {
+		imageHelpers = append(imageHelpers, av.imageHelper(category))
+	}
+
+	assetController := controllers.NewAssetController(av.db, models.NewAssetModel(av.db), av.cfg, imageHelpers, av.privateStorage)
+
+	// Every module referencing an asset needs it uploaded first, uploading is a permission of its own
+	asset := av.authenticated("/assets", av.can("assets.upload"))
+
+	// Echo buffers the whole multipart body before Upload can check the size, cut it off on the way in
+	imageLimit := middleware.BodyLimit(fmt.Sprintf("%dB", av.cfg.AssetStorage.ImageMaxSize+multipartOverhead))
+	asset.POST("", assetController.Upload, imageLimit)
+
+	// Chunks go to the private storage so every instance of the API sees them
+	uploadHelper := helpers.NewUploadHelper(av.privateStorage)
+	fileHelpers := []*helpers.FileHelper{}
+	for _, category := range av.cfg.AssetStorage.FileCategories {
+		fileHelpers = append(fileHelpers, av.fileHelper(category))
+	}
+	assetUploadController := controllers.NewAssetUploadController(av.db, models.NewAssetUploadModel(av.db), models.NewAssetModel(av.db), av.cfg, uploadHelper, imageHelpers, fileHelpers)
+
+	asset.POST("/uploads", assetUploadController.Create)
+	asset.GET("/uploads/:id", assetUploadController.GetById)
+	asset.PATCH("/uploads/:id", assetUploadController.Patch)
+	asset.POST("/uploads/:id/finalize", assetUploadController.Finalize)
+	asset.DELETE("/uploads/:id", assetUploadController.Delete)
+
+	file := av.api.Group("/files", middlewares.VerifySignedURL(av.cfg.AssetStorage.SigningSecret))
+
+	file.GET("/*", assetController.Private, middlewares.AssetHeaders)
+}
+
+func (av *APIVersionOne) AuditLog() {
+	auditLogController := controllers.NewAuditLogController(av.db, models.NewAuditLogModel(av.db), av.cfg)
 
-	// user := av.api.Group("/users", echojwt.WithConfig(av.cfg.JWT.Config))
-	user := av.api.Group("/users")
+	auditLog := av.authenticated("/audit-logs", av.can("audit_logs.view"))
 
-	user.GET("", userController.Index)
-	user.POST("", userController.Create)
-	user.GET("/:id", userController.GetById)
-	user.PUT("", userController.Update)
-	user.DELETE("/:id", userController.Delete)
+	auditLog.GET("", auditLogController.Index)
+	auditLog.GET("/:id", auditLogController.GetById)
 }
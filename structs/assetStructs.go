@@ -0,0 +1,101 @@
+package structs
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (Asset) TableName() string {
+	return "m_assets"
+}
+
+func (AssetReference) TableName() string {
+	return "m_asset_references"
+}
+
+const (
+	AssetPublic  = "public"
+	AssetPrivate = "private"
+)
+
+// Image categories, each holds the images of one field
+const (
+	ProfilePhotos  = "profile_photos"
+	CustomerPhotos = "customer_photos"
+	ProductImages  = "product_images"
+)
+
+type (
+	// Asset is an uploaded file. Every uploader gets an asset of their own, but identical uploads to a
+	// category share the file at Path through Hash, the SHA-256 of the content. ReferenceCount counts the
+	// rows using the asset. Assets stored before hashing have no Hash.
+	Asset struct {
+		ID             uuid.UUID  `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt      time.Time  `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt      time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+		CreatedBy      *uuid.UUID `json:"created_by,omitempty" gorm:"type:char(36);uniqueIndex:idx_asset_uploader_content,priority:3"`
+		Category       string     `json:"category" gorm:"type:varchar(50);not null;index;uniqueIndex:idx_asset_uploader_content,priority:1"`
+		Visibility     string     `json:"visibility" gorm:"type:varchar(10);not null;default:public"`
+		Path           string     `json:"path" gorm:"type:varchar(255);not null;index"`
+		OriginalName   string     `json:"original_name"`
+		MimeType       string     `json:"mime_type" gorm:"type:varchar(100)"`
+		Size           int64      `json:"size"`
+		Hash           *string    `json:"hash,omitempty" gorm:"type:char(64);uniqueIndex:idx_asset_uploader_content,priority:2"`
+		ReferenceCount int        `json:"reference_count" gorm:"not null;default:0"`
+		ReleasedAt     *time.Time `json:"released_at,omitempty" gorm:"index"`
+		// Variants are the sizes of the resized copies written with an image, assets stored before
+		// they were recorded have none
+		Variants VariantSizes `json:"variants,omitempty" gorm:"type:json"`
+		URL      string       `json:"url" gorm:"-"`
+	}
+
+	// VariantSizes is stored as a JSON array, nil as NULL
+	VariantSizes []int
+
+	// AssetReference records which row uses an asset, unreferenced assets are garbage collected
+	AssetReference struct {
+		ID         uuid.UUID `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+		AssetId    uuid.UUID `json:"asset_id" gorm:"type:char(36);not null;index"`
+		OwnerTable string    `json:"owner_table" gorm:"type:varchar(64);not null;uniqueIndex:idx_asset_owner"`
+		OwnerId    uuid.UUID `json:"owner_id" gorm:"type:char(36);not null;uniqueIndex:idx_asset_owner"`
+		Field      string    `json:"field" gorm:"type:varchar(64);not null;uniqueIndex:idx_asset_owner"`
+	}
+)
+
+func (a *Asset) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New()
+	return nil
+}
+
+func (ar *AssetReference) BeforeCreate(tx *gorm.DB) error {
+	ar.ID = uuid.New()
+	return nil
+}
+
+func (vs VariantSizes) Value() (driver.Value, error) {
+	if vs == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(vs)
+	return string(b), err
+}
+
+func (vs *VariantSizes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, vs)
+	case string:
+		return json.Unmarshal([]byte(v), vs)
+	case nil:
+		*vs = nil
+		return nil
+	default:
+		return errors.New("unsupported type for variant sizes")
+	}
+}
@@ -0,0 +1,69 @@
+package structs
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (AssetUpload) TableName() string {
+	return "t_asset_uploads"
+}
+
+type (
+	// AssetUpload tracks a resumable upload, the received chunks are kept in the private storage
+	// until it is finalized
+	AssetUpload struct {
+		ID        uuid.UUID    `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt time.Time    `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+		CreatedBy *uuid.UUID   `json:"created_by,omitempty" gorm:"type:char(36)"`
+		Category  string       `json:"category" gorm:"type:varchar(50);not null"`
+		Filename  string       `json:"filename" gorm:"not null"`
+		Size      int64        `json:"size" gorm:"not null"`
+		Offset    int64        `json:"offset" gorm:"column:upload_offset;not null;default:0"`
+		Chunks    UploadChunks `json:"-" gorm:"type:json"`
+		ExpiresAt time.Time    `json:"expires_at" gorm:"not null;index"`
+	}
+
+	// UploadChunks are the offsets the received chunks start at, in order. It is stored as a JSON
+	// array, nil as NULL.
+	UploadChunks []int64
+
+	AssetUploadRequest struct {
+		Category string `json:"category" validate:"required"`
+		Filename string `json:"filename" validate:"required,max=255"`
+		Size     int64  `json:"size" validate:"required,gt=0"`
+	}
+)
+
+func (au *AssetUpload) BeforeCreate(tx *gorm.DB) error {
+	au.ID = uuid.New()
+	return nil
+}
+
+func (uc UploadChunks) Value() (driver.Value, error) {
+	if uc == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(uc)
+	return string(b), err
+}
+
+func (uc *UploadChunks) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, uc)
+	case string:
+		return json.Unmarshal([]byte(v), uc)
+	case nil:
+		*uc = nil
+		return nil
+	default:
+		return errors.New("unsupported type for upload chunks")
+	}
+}
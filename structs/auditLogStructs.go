@@ -0,0 +1,88 @@
+package structs
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// Actions of an AuditLog
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// Sizes of the AuditLog columns filled from the request, longer values are cut to fit
+const (
+	AuditIPLength        = 45
+	AuditRequestIdLength = 64
+)
+
+// AuditLogQuery is what the audit log can be sorted and filtered by
+var AuditLogQuery = QuerySchema{
+	DefaultSort: "-created_at",
+	Fields: map[string]QueryField{
+		"actor_id":   {Column: "actor_id", Type: QueryUUID, Nullable: true, Operators: EqualOperators},
+		"ip":         {Column: "ip", Type: QueryText, Operators: TextOperators},
+		"request_id": {Column: "request_id", Type: QueryText, Operators: EqualOperators},
+		"table_name": {Column: "table_name", Type: QueryText, Sortable: true, Operators: EqualOperators},
+		"row_id":     {Column: "row_id", Type: QueryText, Operators: EqualOperators},
+		"action":     {Column: "action", Type: QueryText, Sortable: true, Operators: EqualOperators},
+		"created_at": {Column: "created_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+	},
+}
+
+type (
+	// AuditLog records one row created, updated or deleted, by whom and from which request. Entries
+	// are written by the helpers.AuditLogs GORM plugin and never changed afterwards.
+	AuditLog struct {
+		ID        uuid.UUID    `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt time.Time    `json:"created_at" gorm:"autoCreateTime;index"`
+		ActorId   *uuid.UUID   `json:"actor_id" gorm:"type:char(36);index"`
+		IP        string       `json:"ip" gorm:"type:varchar(45)"`
+		RequestId string       `json:"request_id" gorm:"type:varchar(64);index"`
+		Table     string       `json:"table_name" gorm:"column:table_name;type:varchar(64);not null;index:idx_audit_logs_row"`
+		RowId     string       `json:"row_id" gorm:"type:varchar(64);not null;index:idx_audit_logs_row"`
+		Action    string       `json:"action" gorm:"type:varchar(10);not null"`
+		Changes   AuditChanges `json:"changes" gorm:"type:json;not null"`
+	}
+
+	// AuditChanges maps every column that changed to its old and new value. A created row has no
+	// old values and a deleted row no new ones.
+	AuditChanges map[string]AuditChange
+
+	AuditChange struct {
+		Old json.RawMessage `json:"old"`
+		New json.RawMessage `json:"new"`
+	}
+)
+
+func (ac AuditChanges) Value() (driver.Value, error) {
+	if ac == nil {
+		ac = AuditChanges{}
+	}
+	b, err := json.Marshal(ac)
+	return string(b), err
+}
+
+func (ac *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, ac)
+	case string:
+		return json.Unmarshal([]byte(v), ac)
+	case nil:
+		*ac = AuditChanges{}
+		return nil
+	default:
+		return errors.New("unsupported type for audit changes")
+	}
+}
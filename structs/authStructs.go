@@ -0,0 +1,44 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (RefreshToken) TableName() string {
+	return "m_user_refresh_token"
+}
+
+type (
+	RefreshToken struct {
+		ID        uuid.UUID `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+		UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);not null;index"`
+		TokenHash string    `json:"-" gorm:"type:char(64);unique;not null"`
+		ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	}
+
+	LoginRequest struct {
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required"`
+	}
+
+	RefreshTokenRequest struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+
+	TokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+		User         User   `json:"user"`
+	}
+)
+
+func (rt *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	rt.ID = uuid.New()
+	return nil
+}
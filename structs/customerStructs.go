@@ -0,0 +1,68 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (Customer) TableName() string {
+	return "m_customer"
+}
+
+// CustomerQuery is what the customer list can be sorted and filtered by
+var CustomerQuery = QuerySchema{
+	DefaultSort: "-created_at",
+	Fields: map[string]QueryField{
+		"name":         {Column: "name", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"email":        {Column: "email", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"phone_number": {Column: "phone_number", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"address":      {Column: "address", Type: QueryText, Operators: TextOperators},
+		"created_at":   {Column: "created_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+		"updated_at":   {Column: "updated_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+	},
+	Selectable: map[string]string{
+		"id":           "id",
+		"created_at":   "created_at",
+		"updated_at":   "updated_at",
+		"created_by":   "created_by",
+		"updated_by":   "updated_by",
+		"name":         "name",
+		"email":        "email",
+		"phone_number": "phone_number",
+		"address":      "address",
+		"photo_url":    "photo",
+	},
+}
+
+type (
+	Customer struct {
+		ID          uuid.UUID       `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt   *gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+		CreatedBy   *uuid.UUID      `json:"created_by,omitempty" gorm:"type:char(36)"`
+		UpdatedBy   *uuid.UUID      `json:"updated_by,omitempty" gorm:"type:char(36)"`
+		DeletedBy   *uuid.UUID      `json:"deleted_by,omitempty" gorm:"type:char(36)"`
+		Name        string          `json:"name" gorm:"not null;index"`
+		Email       string          `json:"email" gorm:"index"`
+		PhoneNumber string          `json:"phone_number" gorm:"not null;index"`
+		Address     string          `json:"address" gorm:"type:text"`
+		Photo       string          `json:"photo_url,omitempty"`
+	}
+
+	CustomerRequest struct {
+		ID           uuid.UUID  `json:"id"`
+		Name         string     `json:"name" validate:"required"`
+		Email        string     `json:"email" validate:"omitempty,email"`
+		PhoneNumber  string     `json:"phone_number" validate:"required,e164"`
+		Address      string     `json:"address"`
+		PhotoAssetId *uuid.UUID `json:"photo_asset_id"`
+	}
+)
+
+func (cu *Customer) BeforeCreate(tx *gorm.DB) error {
+	cu.ID = uuid.New()
+	return nil
+}
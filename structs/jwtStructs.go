@@ -1,13 +1,15 @@
 package structs
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 type JWTUser struct {
-	Email           string      `json:"email"`
-	ID              uuid.UUID   `json:"id"`
-	UpdatedSecurity interface{} `json:"updated_security"`
+	Email           string    `json:"email"`
+	ID              uuid.UUID `json:"id"`
+	UpdatedSecurity time.Time `json:"updated_security"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,37 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (ProductCategory) TableName() string {
+	return "m_product_category"
+}
+
+type (
+	ProductCategory struct {
+		ID          uuid.UUID       `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt   *gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+		CreatedBy   *uuid.UUID      `json:"created_by,omitempty" gorm:"type:char(36)"`
+		UpdatedBy   *uuid.UUID      `json:"updated_by,omitempty" gorm:"type:char(36)"`
+		DeletedBy   *uuid.UUID      `json:"deleted_by,omitempty" gorm:"type:char(36)"`
+		Name        string          `json:"name" gorm:"type:varchar(100);unique;not null"`
+		Description string          `json:"description" gorm:"type:text"`
+	}
+
+	ProductCategoryRequest struct {
+		ID          uuid.UUID `json:"id"`
+		Name        string    `json:"name" validate:"required,max=100"`
+		Description string    `json:"description"`
+	}
+)
+
+func (pc *ProductCategory) BeforeCreate(tx *gorm.DB) error {
+	pc.ID = uuid.New()
+	return nil
+}
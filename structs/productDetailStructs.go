@@ -0,0 +1,44 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (ProductDetail) TableName() string {
+	return "m_product_detail"
+}
+
+type (
+	// ProductDetail is a variant or option of a product, such as a size or a topping
+	ProductDetail struct {
+		ID          uuid.UUID       `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt   *gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+		CreatedBy   *uuid.UUID      `json:"created_by,omitempty" gorm:"type:char(36)"`
+		UpdatedBy   *uuid.UUID      `json:"updated_by,omitempty" gorm:"type:char(36)"`
+		DeletedBy   *uuid.UUID      `json:"deleted_by,omitempty" gorm:"type:char(36)"`
+		ProductId   uuid.UUID       `json:"product_id" gorm:"type:char(36);not null;index"`
+		Type        string          `json:"type" gorm:"type:varchar(50);not null"`
+		Description string          `json:"description" gorm:"not null"`
+		PriceDelta  float64         `json:"price_delta" gorm:"type:decimal(15,2);not null;default:0"`
+		Stock       int             `json:"stock" gorm:"not null;default:0"`
+	}
+
+	ProductDetailRequest struct {
+		ID          uuid.UUID `json:"id"`
+		ProductId   uuid.UUID `json:"-"`
+		Type        string    `json:"type" validate:"required,oneof=size topping"`
+		Description string    `json:"description" validate:"required"`
+		PriceDelta  float64   `json:"price_delta"`
+		Stock       int       `json:"stock" validate:"gte=0"`
+	}
+)
+
+func (pd *ProductDetail) BeforeCreate(tx *gorm.DB) error {
+	pd.ID = uuid.New()
+	return nil
+}
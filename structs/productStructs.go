@@ -0,0 +1,89 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (Product) TableName() string {
+	return "m_product"
+}
+
+// ProductQuery is what the product list can be sorted and filtered by
+var ProductQuery = QuerySchema{
+	DefaultSort: "-created_at",
+	Fields: map[string]QueryField{
+		"name":                {Column: "name", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"sku":                 {Column: "sku", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"price":               {Column: "price", Type: QueryNumber, Sortable: true, Operators: RangeOperators},
+		"is_active":           {Column: "is_active", Type: QueryBool, Sortable: true, Operators: EqualOperators},
+		"product_category_id": {Column: "product_category_id", Type: QueryUUID, Operators: EqualOperators},
+		"created_at":          {Column: "created_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+		"updated_at":          {Column: "updated_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+	},
+	Selectable: map[string]string{
+		"id":                  "id",
+		"created_at":          "created_at",
+		"updated_at":          "updated_at",
+		"created_by":          "created_by",
+		"updated_by":          "updated_by",
+		"product_category_id": "product_category_id",
+		"name":                "name",
+		"sku":                 "sku",
+		"description":         "description",
+		"price":               "price",
+		"is_active":           "is_active",
+		"image_url":           "image",
+	},
+	Includes: map[string]QueryInclude{
+		"category": {Preload: "Category", Column: "product_category_id"},
+		"details":  {Preload: "Details"},
+	},
+}
+
+type (
+	Product struct {
+		ID                uuid.UUID        `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt         time.Time        `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt         time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt         *gorm.DeletedAt  `json:"deleted_at,omitempty" gorm:"index"`
+		CreatedBy         *uuid.UUID       `json:"created_by,omitempty" gorm:"type:char(36)"`
+		UpdatedBy         *uuid.UUID       `json:"updated_by,omitempty" gorm:"type:char(36)"`
+		DeletedBy         *uuid.UUID       `json:"deleted_by,omitempty" gorm:"type:char(36)"`
+		ProductCategoryId uuid.UUID        `json:"product_category_id" gorm:"type:char(36);not null;index"`
+		Category          *ProductCategory `json:"category,omitempty" gorm:"foreignKey:ProductCategoryId"`
+		Name              string           `json:"name" gorm:"not null;index"`
+		SKU               string           `json:"sku" gorm:"column:sku;type:varchar(64);unique;not null"`
+		Description       string           `json:"description" gorm:"type:text"`
+		Price             float64          `json:"price" gorm:"type:decimal(15,2);not null;index"`
+		IsActive          bool             `json:"is_active" gorm:"not null;index"`
+		Image             string           `json:"image_url,omitempty"`
+		Details           []ProductDetail  `json:"details,omitempty" gorm:"foreignKey:ProductId"`
+	}
+
+	ProductRequest struct {
+		ID                uuid.UUID  `json:"id"`
+		ProductCategoryId uuid.UUID  `json:"product_category_id" validate:"required"`
+		Name              string     `json:"name" validate:"required"`
+		SKU               string     `json:"sku" validate:"required,max=64"`
+		Description       string     `json:"description"`
+		Price             float64    `json:"price" validate:"gte=0"`
+		IsActive          *bool      `json:"is_active"`
+		ImageAssetId      *uuid.UUID `json:"image_asset_id"`
+	}
+
+	ProductFilter struct {
+		Search            string
+		ProductCategoryId *uuid.UUID
+		MinPrice          *float64
+		MaxPrice          *float64
+		IsActive          *bool
+	}
+)
+
+func (p *Product) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New()
+	return nil
+}
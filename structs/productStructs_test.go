@@ -0,0 +1,41 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, SkipDefaultTransaction: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// insertedValue returns the value an INSERT of one row writes to the column
+func insertedValue(t *testing.T, stmt *gorm.Statement, column string) interface{} {
+	t.Helper()
+	sql := stmt.SQL.String()
+	columns := strings.Split(sql[strings.Index(sql, "(")+1:strings.Index(sql, ")")], ",")
+	for i, name := range columns {
+		if strings.Trim(name, "` ") == column {
+			return stmt.Vars[i]
+		}
+	}
+	t.Fatalf("%s is not inserted by %s", column, sql)
+	return nil
+}
+
+func TestProductCreateKeepsIsActiveFalse(t *testing.T) {
+	for _, active := range []bool{false, true} {
+		stmt := dryRunDB(t).Create(&Product{Name: "Tea", SKU: "TEA", IsActive: active}).Statement
+		if got := insertedValue(t, stmt, "is_active"); got != active {
+			t.Errorf("is_active %v is inserted as %v", active, got)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package structs
+
+import "maps"
+
+// Types of a QueryField, they decide how filter values are parsed
+const (
+	QueryText   = "text"
+	QueryNumber = "number"
+	QueryBool   = "bool"
+	QueryTime   = "time"
+	QueryUUID   = "uuid"
+)
+
+// Operator sets for QueryField.Operators, filter[field]=value without an operator means eq
+var (
+	TextOperators  = []string{"eq", "ne", "like", "in"}
+	RangeOperators = []string{"eq", "ne", "gt", "gte", "lt", "lte", "in"}
+	EqualOperators = []string{"eq", "ne", "in"}
+)
+
+type (
+	// QuerySchema is the allowlist of the fields a list endpoint can be sorted and filtered by.
+	// Only columns named here ever reach the SQL, whatever the client sends.
+	QuerySchema struct {
+		Fields map[string]QueryField
+		// DefaultSort uses the sort parameter syntax, e.g. -created_at
+		DefaultSort string
+		// Selectable maps the JSON fields ?fields= accepts to the column they are read from. Fields
+		// computed from a column map to that column. Columns missing here, like password, are
+		// never selected.
+		Selectable map[string]string
+		// Includes maps the relations ?include= accepts, named after their JSON field
+		Includes map[string]QueryInclude
+	}
+
+	QueryField struct {
+		Column    string
+		Type      string
+		Sortable  bool
+		Nullable  bool
+		Operators []string
+	}
+
+	QueryInclude struct {
+		Preload string
+		// Column is the foreign key the relation is loaded through, empty when it points back to the id
+		Column string
+		// Nested are relations of the included rows loaded with them, e.g. Product for Details.Product
+		Nested []string
+	}
+
+	// FieldSet is a parsed ?fields= and ?include=, the zero value selects every selectable column
+	FieldSet struct {
+		// Fields are the JSON fields kept in the response, nil keeps them all
+		Fields   []string
+		Columns  []string
+		Preloads []string
+	}
+
+	// ListQuery is a parsed sort and filter, its columns come from a QuerySchema
+	ListQuery struct {
+		Sorts   []QuerySort
+		Filters []QueryFilter
+	}
+
+	// QuerySort carries the type of its field so cursors can be decoded back into values
+	QuerySort struct {
+		Column   string
+		Desc     bool
+		Type     string
+		Nullable bool
+	}
+
+	QueryFilter struct {
+		Column   string
+		Operator string
+		// Value is already parsed to the field type, a slice for in and a bool for null
+		Value interface{}
+	}
+
+	// Pagination is the page a client asked for. Paginate fills in Total, in offset mode, and
+	// NextCursor, in cursor mode, once the page is loaded.
+	Pagination struct {
+		Page    int
+		PerPage int
+		// Cursor is nil in offset mode and empty for the first page in cursor mode
+		Cursor     *string
+		Total      int64
+		NextCursor string
+	}
+)
+
+// Trashed derives the schema of the trash of a resource, which can also be sorted and filtered
+// by when and by whom rows were deleted and lists the latest deletion first
+func (qs QuerySchema) Trashed() QuerySchema {
+	trashed := QuerySchema{
+		Fields:      maps.Clone(qs.Fields),
+		DefaultSort: "-deleted_at",
+		Selectable:  maps.Clone(qs.Selectable),
+		Includes:    qs.Includes,
+	}
+	trashed.Fields["deleted_at"] = QueryField{Column: "deleted_at", Type: QueryTime, Sortable: true, Operators: RangeOperators}
+	trashed.Fields["deleted_by"] = QueryField{Column: "deleted_by", Type: QueryUUID, Nullable: true, Operators: EqualOperators}
+	trashed.Selectable["deleted_at"] = "deleted_at"
+	trashed.Selectable["deleted_by"] = "deleted_by"
+	return trashed
+}
+
+// Offset is the number of rows before the page in offset mode
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
@@ -0,0 +1,41 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	ReportFilter struct {
+		StartDate time.Time
+		EndDate   time.Time
+		GroupBy   string
+		OrderBy   string
+		Limit     int
+	}
+
+	SalesSummary struct {
+		Period            string  `json:"period"`
+		TotalTransactions int     `json:"total_transactions"`
+		Subtotal          float64 `json:"subtotal"`
+		Discount          float64 `json:"discount"`
+		Tax               float64 `json:"tax"`
+		GrandTotal        float64 `json:"grand_total"`
+	}
+
+	TopProduct struct {
+		ProductId uuid.UUID `json:"product_id"`
+		Name      string    `json:"name"`
+		SKU       string    `json:"sku" gorm:"column:sku"`
+		Quantity  int       `json:"quantity"`
+		Revenue   float64   `json:"revenue"`
+	}
+
+	CustomerRevenue struct {
+		CustomerId        uuid.UUID `json:"customer_id"`
+		Name              string    `json:"name"`
+		TotalTransactions int       `json:"total_transactions"`
+		Revenue           float64   `json:"revenue"`
+	}
+)
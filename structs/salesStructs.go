@@ -0,0 +1,125 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (Sale) TableName() string {
+	return "t_sales"
+}
+
+func (SaleDetail) TableName() string {
+	return "t_sales_detail"
+}
+
+func (InvoiceSequence) TableName() string {
+	return "m_invoice_sequence"
+}
+
+// SaleQuery is what the sales list can be sorted and filtered by
+var SaleQuery = QuerySchema{
+	DefaultSort: "-date",
+	Fields: map[string]QueryField{
+		"invoice_number": {Column: "invoice_number", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"customer_id":    {Column: "customer_id", Type: QueryUUID, Operators: EqualOperators},
+		"date":           {Column: "date", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+		"subtotal":       {Column: "subtotal", Type: QueryNumber, Sortable: true, Operators: RangeOperators},
+		"discount":       {Column: "discount", Type: QueryNumber, Sortable: true, Operators: RangeOperators},
+		"grand_total":    {Column: "grand_total", Type: QueryNumber, Sortable: true, Operators: RangeOperators},
+		"created_at":     {Column: "created_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+	},
+	Selectable: map[string]string{
+		"id":             "id",
+		"created_at":     "created_at",
+		"updated_at":     "updated_at",
+		"created_by":     "created_by",
+		"updated_by":     "updated_by",
+		"invoice_number": "invoice_number",
+		"customer_id":    "customer_id",
+		"date":           "date",
+		"subtotal":       "subtotal",
+		"discount":       "discount",
+		"tax_rate":       "tax_rate",
+		"tax":            "tax",
+		"grand_total":    "grand_total",
+	},
+	Includes: map[string]QueryInclude{
+		"customer": {Preload: "Customer", Column: "customer_id"},
+		"details":  {Preload: "Details", Nested: []string{"Product", "ProductDetail"}},
+	},
+}
+
+type (
+	Sale struct {
+		ID            uuid.UUID       `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt     time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt     *gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+		CreatedBy     *uuid.UUID      `json:"created_by,omitempty" gorm:"type:char(36)"`
+		UpdatedBy     *uuid.UUID      `json:"updated_by,omitempty" gorm:"type:char(36)"`
+		DeletedBy     *uuid.UUID      `json:"deleted_by,omitempty" gorm:"type:char(36)"`
+		InvoiceNumber string          `json:"invoice_number" gorm:"type:varchar(32);unique;not null"`
+		CustomerId    uuid.UUID       `json:"customer_id" gorm:"type:char(36);not null;index"`
+		Customer      *Customer       `json:"customer,omitempty" gorm:"foreignKey:CustomerId"`
+		Date          time.Time       `json:"date" gorm:"not null;index"`
+		Subtotal      float64         `json:"subtotal" gorm:"type:decimal(15,2);not null"`
+		Discount      float64         `json:"discount" gorm:"type:decimal(15,2);not null;default:0"`
+		TaxRate       float64         `json:"tax_rate" gorm:"type:decimal(5,2);not null;default:0"`
+		Tax           float64         `json:"tax" gorm:"type:decimal(15,2);not null;default:0"`
+		GrandTotal    float64         `json:"grand_total" gorm:"type:decimal(15,2);not null"`
+		Details       []SaleDetail    `json:"details,omitempty" gorm:"foreignKey:SaleId"`
+	}
+
+	SaleDetail struct {
+		ID              uuid.UUID      `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt       time.Time      `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt       time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+		SaleId          uuid.UUID      `json:"sale_id" gorm:"type:char(36);not null;index"`
+		ProductId       uuid.UUID      `json:"product_id" gorm:"type:char(36);not null;index"`
+		Product         *Product       `json:"product,omitempty" gorm:"foreignKey:ProductId"`
+		ProductDetailId *uuid.UUID     `json:"product_detail_id,omitempty" gorm:"type:char(36);index"`
+		ProductDetail   *ProductDetail `json:"product_detail,omitempty" gorm:"foreignKey:ProductDetailId"`
+		Quantity        int            `json:"quantity" gorm:"not null"`
+		Price           float64        `json:"price" gorm:"type:decimal(15,2);not null"`
+		Subtotal        float64        `json:"subtotal" gorm:"type:decimal(15,2);not null"`
+	}
+
+	// InvoiceSequence keeps the last invoice number issued per month
+	InvoiceSequence struct {
+		Period     string `gorm:"primaryKey;type:varchar(7)"`
+		LastNumber int    `gorm:"not null"`
+	}
+
+	SaleRequest struct {
+		CustomerId uuid.UUID           `json:"customer_id" validate:"required"`
+		Date       *time.Time          `json:"date"`
+		Discount   float64             `json:"discount" validate:"gte=0"`
+		Details    []SaleDetailRequest `json:"details" validate:"required,min=1,dive"`
+	}
+
+	SaleDetailRequest struct {
+		ProductId       uuid.UUID  `json:"product_id" validate:"required"`
+		ProductDetailId *uuid.UUID `json:"product_detail_id"`
+		Quantity        int        `json:"quantity" validate:"required,gt=0"`
+	}
+
+	SaleFilter struct {
+		Search     string
+		CustomerId *uuid.UUID
+		StartDate  *time.Time
+		EndDate    *time.Time
+	}
+)
+
+func (s *Sale) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New()
+	return nil
+}
+
+func (sd *SaleDetail) BeforeCreate(tx *gorm.DB) error {
+	sd.ID = uuid.New()
+	return nil
+}
@@ -7,10 +7,12 @@ import (
 )
 
 type JSONResponse struct {
-	ResponseCode    int         `json:"response_code"`
-	ResponseMessage string      `json:"response_message"`
-	Message         string      `json:"message,omitempty"`
-	Data            interface{} `json:"data"`
+	ResponseCode    int                 `json:"response_code"`
+	ResponseMessage string              `json:"response_message"`
+	Message         string              `json:"message,omitempty"`
+	ErrorCode       string              `json:"error_code,omitempty"`
+	Errors          map[string][]string `json:"errors,omitempty"`
+	Data            interface{}         `json:"data"`
 }
 
 type Delete struct {
@@ -39,6 +41,18 @@ type PagedData struct {
 	Meta MetaData    `json:"metadata"`
 }
 
+// MetaData describes the page of a PagedData. Total, page and last_page are only known in offset
+// mode, next_cursor only in cursor mode.
 type MetaData struct {
-	Total int `json:"total"`
+	Total      *int64    `json:"total,omitempty"`
+	PerPage    int       `json:"per_page"`
+	Page       int       `json:"page,omitempty"`
+	LastPage   int       `json:"last_page,omitempty"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Links      PageLinks `json:"links"`
+}
+
+type PageLinks struct {
+	Next *string `json:"next"`
+	Prev *string `json:"prev"`
 }
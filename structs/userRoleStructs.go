@@ -0,0 +1,110 @@
+package structs
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const PermissionAll = "*"
+
+// SuperAdminRole is seeded with PermissionAll, users:create-admin hands it out
+const SuperAdminRole = "Super Admin"
+
+// AvailablePermissions lists every permission a role may be granted
+var AvailablePermissions = []string{
+	"roles.view",
+	"roles.create",
+	"roles.update",
+	"roles.delete",
+	"users.view",
+	"users.create",
+	"users.update",
+	"users.delete",
+	"users.purge",
+	"users.lock",
+	"users.assign-role",
+	"customers.view",
+	"customers.create",
+	"customers.update",
+	"customers.delete",
+	"customers.purge",
+	"product_categories.view",
+	"product_categories.create",
+	"product_categories.update",
+	"product_categories.delete",
+	"products.view",
+	"products.create",
+	"products.update",
+	"products.delete",
+	"products.purge",
+	"sales.view",
+	"sales.create",
+	"sales.delete",
+	"sales.purge",
+	"reports.view",
+	"assets.upload",
+	"audit_logs.view",
+}
+
+func (UserRole) TableName() string {
+	return "m_user_roles"
+}
+
+type (
+	Permissions []string
+
+	UserRole struct {
+		ID          uuid.UUID       `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt   time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt   *gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+		CreatedBy   *uuid.UUID      `json:"created_by,omitempty" gorm:"type:char(36)"`
+		UpdatedBy   *uuid.UUID      `json:"updated_by,omitempty" gorm:"type:char(36)"`
+		DeletedBy   *uuid.UUID      `json:"deleted_by,omitempty" gorm:"type:char(36)"`
+		Name        string          `json:"name" gorm:"type:varchar(100);unique;not null"`
+		Permissions Permissions     `json:"permissions" gorm:"type:json;not null"`
+	}
+
+	UserRoleRequest struct {
+		ID          uuid.UUID `json:"id"`
+		Name        string    `json:"name" validate:"required,max=100"`
+		Permissions []string  `json:"permissions" validate:"required,dive,required"`
+	}
+)
+
+func (r *UserRole) BeforeCreate(tx *gorm.DB) error {
+	r.ID = uuid.New()
+	return nil
+}
+
+func (p Permissions) Has(permission string) bool {
+	return slices.Contains(p, PermissionAll) || slices.Contains(p, permission)
+}
+
+func (p Permissions) Value() (driver.Value, error) {
+	if p == nil {
+		p = Permissions{}
+	}
+	b, err := json.Marshal(p)
+	return string(b), err
+}
+
+func (p *Permissions) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	case nil:
+		*p = Permissions{}
+		return nil
+	default:
+		return errors.New("unsupported type for permissions")
+	}
+}
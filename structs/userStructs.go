@@ -11,32 +11,106 @@ func (User) TableName() string {
 	return "m_user"
 }
 
+// UserQuery is what the user list can be sorted and filtered by
+var UserQuery = QuerySchema{
+	DefaultSort: "-created_at",
+	Fields: map[string]QueryField{
+		"name":          {Column: "name", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"email":         {Column: "email", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"phone_number":  {Column: "phone_number", Type: QueryText, Sortable: true, Operators: TextOperators},
+		"user_roles_id": {Column: "user_roles_id", Type: QueryUUID, Operators: EqualOperators},
+		"language":      {Column: "language", Type: QueryText, Operators: EqualOperators},
+		"locked_at":     {Column: "locked_at", Type: QueryTime, Sortable: true, Nullable: true, Operators: RangeOperators},
+		"created_at":    {Column: "created_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+		"updated_at":    {Column: "updated_at", Type: QueryTime, Sortable: true, Operators: RangeOperators},
+	},
+	Selectable: map[string]string{
+		"id":               "id",
+		"created_at":       "created_at",
+		"updated_at":       "updated_at",
+		"created_by":       "created_by",
+		"updated_by":       "updated_by",
+		"name":             "name",
+		"email":            "email",
+		"photo_url":        "photo",
+		"photo_urls":       "photo",
+		"phone_number":     "phone_number",
+		"language":         "language",
+		"user_roles_id":    "user_roles_id",
+		"updated_security": "updated_security",
+		"locked_at":        "locked_at",
+	},
+	Includes: map[string]QueryInclude{
+		"role": {Preload: "Role", Column: "user_roles_id"},
+	},
+}
+
 type (
 	User struct {
-		ID              uuid.UUID       `json:"id" gorm:"primaryKey;type:char(36);not null"`
-		CreatedAt       time.Time       `json:"created_at" gorm:"autoCreateTime"`
-		UpdatedAt       time.Time       `json:"updated_at" gorm:"autoUpdateTime"`
-		DeletedAt       *gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
-		CreatedBy       *uuid.UUID      `json:"created_by,omitempty" gorm:"type:char(36)"`
-		UpdatedBy       *uuid.UUID      `json:"updated_by,omitempty" gorm:"type:char(36)"`
-		DeletedBy       *uuid.UUID      `json:"deleted_by,omitempty" gorm:"type:char(36)"`
-		Name            string          `json:"name" gorm:"not null"`
-		Email           string          `json:"email" gorm:"unique;not null"`
-		Photo           string          `json:"photo_url,omitempty"`
-		PhoneNumber     string          `json:"phone_number" gorm:"not null"`
-		Password        string          `json:"password,omitempty" gorm:"not null"`
-		UserRolesId     string          `json:"user_roles_id" gorm:"type:char(36)"`
-		UpdatedSecurity time.Time       `json:"updated_security"`
+		ID              uuid.UUID         `json:"id" gorm:"primaryKey;type:char(36);not null"`
+		CreatedAt       time.Time         `json:"created_at" gorm:"autoCreateTime"`
+		UpdatedAt       time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+		DeletedAt       *gorm.DeletedAt   `json:"deleted_at,omitempty" gorm:"index"`
+		CreatedBy       *uuid.UUID        `json:"created_by,omitempty" gorm:"type:char(36)"`
+		UpdatedBy       *uuid.UUID        `json:"updated_by,omitempty" gorm:"type:char(36)"`
+		DeletedBy       *uuid.UUID        `json:"deleted_by,omitempty" gorm:"type:char(36)"`
+		Name            string            `json:"name" gorm:"not null"`
+		Email           string            `json:"email" gorm:"unique;not null"`
+		Photo           string            `json:"photo_url,omitempty"`
+		PhotoAssetId    *uuid.UUID        `json:"photo_asset_id,omitempty" gorm:"-"`
+		PhotoUrls       map[string]string `json:"photo_urls,omitempty" gorm:"-"`
+		PhoneNumber     string            `json:"phone_number" gorm:"not null"`
+		Language        string            `json:"language,omitempty" gorm:"type:varchar(10)" validate:"omitempty,oneof=en id"`
+		Password        string            `json:"password,omitempty" gorm:"not null"`
+		UserRolesId     string            `json:"user_roles_id" gorm:"type:char(36)"`
+		Role            *UserRole         `json:"role,omitempty" gorm:"foreignKey:UserRolesId"`
+		UpdatedSecurity time.Time         `json:"updated_security"`
+		LockedAt        *time.Time        `json:"locked_at,omitempty"`
 	}
 
 	UserRequest struct {
-		Name            string    `json:"name" validate:"required"`
-		Email           string    `json:"email" validate:"required,email"`
-		Photo           string    `json:"photo_url,omitempty"`
-		PhoneNumber     string    `json:"phone_number" validate:"required,e164"`
-		Password        string    `json:"password" validate:"required"`
-		UserRolesId     string    `json:"user_roles_id" validate:"required,uuid"`
-		UpdatedSecurity time.Time `json:"updated_security"`
+		Name            string     `json:"name" validate:"required"`
+		Email           string     `json:"email" validate:"required,email"`
+		PhotoAssetId    *uuid.UUID `json:"photo_asset_id"`
+		PhoneNumber     string     `json:"phone_number" validate:"required,e164"`
+		Language        string     `json:"language" validate:"omitempty,oneof=en id"`
+		Password        string     `json:"password" validate:"required"`
+		UserRolesId     string     `json:"user_roles_id" validate:"required,uuid"`
+		UpdatedSecurity time.Time  `json:"updated_security"`
+	}
+
+	// UserUpdateRequest holds what an update may change, the role has its own endpoint and the
+	// audit columns are never taken from the client
+	UserUpdateRequest struct {
+		ID           uuid.UUID  `json:"id" validate:"required"`
+		Name         string     `json:"name" validate:"required"`
+		Email        string     `json:"email" validate:"required,email"`
+		PhotoAssetId *uuid.UUID `json:"photo_asset_id"`
+		PhoneNumber  string     `json:"phone_number" validate:"required,e164"`
+		Language     string     `json:"language" validate:"omitempty,oneof=en id"`
+		Password     string     `json:"password" validate:"omitempty,min=8"`
+	}
+
+	SetRoleRequest struct {
+		UserRolesId string `json:"user_roles_id" validate:"required,uuid"`
+	}
+
+	SignUpRequest struct {
+		Name        string `json:"name" validate:"required"`
+		Email       string `json:"email" validate:"required,email"`
+		PhoneNumber string `json:"phone_number" validate:"required,e164"`
+		Language    string `json:"language" validate:"omitempty,oneof=en id"`
+		Password    string `json:"password" validate:"required"`
+	}
+
+	// LanguageRequest saves the language responses use for the authenticated user, empty follows Accept-Language
+	LanguageRequest struct {
+		Language string `json:"language" validate:"omitempty,oneof=en id"`
+	}
+
+	ChangePasswordRequest struct {
+		OldPassword string `json:"old_password" validate:"required"`
+		NewPassword string `json:"new_password" validate:"required,min=8"`
 	}
 )
 